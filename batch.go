@@ -0,0 +1,215 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Batch accumulates JSON-RPC calls to send them to PayMe API in a single HTTP request.
+// All calls are authenticated with PayMe ID and key, and the batch is never retried,
+// so receipts.pay calls are safe to include.
+// A Batch is not safe for concurrent use, build it in a single goroutine.
+type Batch struct {
+	client *Client
+	calls  []batchCall
+}
+
+// batchCall contains a single call of the batch.
+type batchCall struct {
+	id     string
+	method string
+	params interface{}
+}
+
+// BatchCallResult contains the result of a single batch call.
+// Result is the raw JSON result, and Err is the PayMe error of the call, if any.
+type BatchCallResult struct {
+	ID     string
+	Method string
+	Result json.RawMessage
+	Err    error
+}
+
+// Decode unmarshals the raw call result into v, e.g. *CreateReceiptResponse.
+// Returns the call error if the call failed.
+func (r *BatchCallResult) Decode(v interface{}) error {
+	if r.Err != nil {
+		return r.Err
+	}
+	if len(r.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(r.Result, v); err != nil {
+		return fmt.Errorf("result unmarshal error: %w", err)
+	}
+	return nil
+}
+
+// batchResponse represents a single response of the batch response array.
+type batchResponse struct {
+	ID     string          `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *Error          `json:"error,omitempty"`
+}
+
+// NewBatch creates an empty batch of calls sent with the client.
+// Returns a pointer to Batch.
+func (c *Client) NewBatch() *Batch {
+	return &Batch{client: c}
+}
+
+// Add appends a call with the caller assigned id to the batch.
+// The id is used to match the call result and must be unique within the batch.
+// Returns the batch for chaining.
+func (b *Batch) Add(id, method string, params interface{}) *Batch {
+	b.calls = append(b.calls, batchCall{id: id, method: method, params: params})
+	return b
+}
+
+// Len returns the number of calls in the batch.
+func (b *Batch) Len() int {
+	return len(b.calls)
+}
+
+// SendBatch sends all calls as a JSON array in a single HTTP request.
+// The response array is matched to the calls by id, and a call without response
+// gets ErrResponseIDMismatch as its error.
+// The batch is traced, logged and measured as the "batch" method, and LastRequestID
+// returns the comma separated call ids.
+// Returns the call results keyed by id, or an error if the whole request failed.
+func (b *Batch) SendBatch(ctx context.Context, opts ...RequestOption) (results map[string]*BatchCallResult, err error) {
+	c := b.client
+	if c.isClosed() {
+		return nil, ErrClientClosed
+	}
+
+	// Validation
+	if len(b.calls) == 0 {
+		return nil, fmt.Errorf("batch is empty: %w", ErrInvalidParams)
+	}
+
+	results = make(map[string]*BatchCallResult, len(b.calls))
+	ids := make([]string, 0, len(b.calls))
+	data := make([]map[string]interface{}, 0, len(b.calls))
+	for _, call := range b.calls {
+		if call.id == "" || call.method == "" {
+			return nil, fmt.Errorf("batch call must have id and method: %w", ErrInvalidParams)
+		}
+		if _, ok := results[call.id]; ok {
+			return nil, fmt.Errorf("duplicate batch call id %q: %w", call.id, ErrInvalidParams)
+		}
+
+		results[call.id] = &BatchCallResult{ID: call.id, Method: call.method}
+		ids = append(ids, call.id)
+
+		callData := map[string]interface{}{
+			"id":     call.id,
+			"method": call.method,
+			"params": call.params,
+		}
+		if c.JSONRPCVersion != "" {
+			callData["jsonrpc"] = c.JSONRPCVersion
+		}
+		data = append(data, callData)
+	}
+
+	requestBody, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal error: %w", err)
+	}
+
+	requestID := strings.Join(ids, ",")
+
+	ctx, span := c.startSpan(ctx, "batch")
+	defer span.End()
+
+	span.SetAttribute("payme.method", "batch")
+	span.SetAttribute("payme.request_id", requestID)
+	span.SetAttribute("payme.batch_size", len(b.calls))
+
+	if c.SlogLogger != nil {
+		defer func() {
+			c.logRequest(ctx, "batch", requestID, nil, err)
+		}()
+	}
+
+	if c.Metrics != nil {
+		start := time.Now()
+		defer func() {
+			c.Metrics.ObserveCall("batch", time.Since(start), err)
+		}()
+	}
+
+	c.setLastRequestID(requestID)
+
+	if c.DryRun {
+		c.logf("PayMe dry run batch - body - %s", requestBody)
+		for _, call := range b.calls {
+			results[call.id].Result, _ = json.Marshal(c.dryRunResult(call.method))
+		}
+		return results, nil
+	}
+
+	if c.RateLimiter != nil {
+		if err := c.RateLimiter.Wait(ctx); err != nil {
+			return nil, fmt.Errorf("rate limiter wait error: %w", err)
+		}
+	}
+
+	responses, err := c.doBatchRequest(ctx, span, requestBody, opts...)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	// Parse result
+	for _, response := range responses {
+		result, ok := results[response.ID]
+		if !ok {
+			c.logf("PayMe batch response with unknown id - %s", response.ID)
+			continue
+		}
+
+		result.Result = response.Result
+		if response.Error != nil {
+			_, result.Err = c.handleErrorResponse(Response{ID: response.ID, Error: response.Error})
+		}
+	}
+
+	for id, result := range results {
+		if result.Result == nil && result.Err == nil {
+			result.Err = fmt.Errorf("%w (no response for batch call id - %s)", ErrResponseIDMismatch, id)
+		}
+	}
+
+	return results, nil
+}
+
+// doBatchRequest sends the batch request body to PayMe API and parses the response array.
+// Returns the responses of all calls or any error of the request.
+func (c *Client) doBatchRequest(ctx context.Context, span Span, requestBody []byte, opts ...RequestOption) (responses []batchResponse, err error) {
+	timeout := c.Timeout
+	if o := applyOptions(opts); o.timeout > 0 {
+		timeout = o.timeout
+	}
+
+	_, err = c.send(ctx, span, "batch", requestBody, false, timeout, func(response *http.Response, responseBody []byte) error {
+		// Parse response
+		if err := json.Unmarshal(responseBody, &responses); err != nil {
+			return &HTTPStatusError{
+				StatusCode: response.StatusCode,
+				Err:        fmt.Errorf("json unmarshal error: %w, body - %s", err, TruncateString(string(responseBody), maxBodySnippet)),
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return responses, nil
+}
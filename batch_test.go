@@ -0,0 +1,222 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newBatchServer starts a test server answering batch requests with the handler.
+// Request headers of the last request are stored in header.
+func newBatchServer(t *testing.T, header *http.Header, handler func(calls []rpcCall) interface{}) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*header = r.Header.Clone()
+
+		var calls []rpcCall
+		if err := json.NewDecoder(r.Body).Decode(&calls); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(handler(calls))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestSendBatchMatchesResultsByID(t *testing.T) {
+	var header http.Header
+	server := newBatchServer(t, &header, func(calls []rpcCall) interface{} {
+		// Reversed order with an error for the second call
+		return []map[string]interface{}{
+			{"id": calls[1].ID, "error": Error{Code: ReceiptNotFoundErrorCode, Message: "not found"}},
+			{"id": calls[0].ID, "result": receiptResult(Receipt{ID: "receipt-1"})},
+		}
+	})
+	client := newTestClient(t, server.URL)
+
+	results, err := client.NewBatch().
+		Add("first", "receipts.get", map[string]interface{}{"id": "receipt-1"}).
+		Add("second", "receipts.get", map[string]interface{}{"id": "receipt-2"}).
+		Add("third", "receipts.get", map[string]interface{}{"id": "receipt-3"}).
+		SendBatch(context.Background())
+	if err != nil {
+		t.Fatalf("SendBatch error: %v", err)
+	}
+
+	var first GetReceiptResponse
+	if err := results["first"].Decode(&first); err != nil || first.Receipt.ID != "receipt-1" {
+		t.Errorf("first = %+v, %v, want receipt-1", first.Receipt, err)
+	}
+	if err := results["second"].Err; !errors.Is(err, ErrReceiptNotFound) {
+		t.Errorf("second error = %v, want ErrReceiptNotFound", err)
+	}
+	if err := results["third"].Err; !errors.Is(err, ErrResponseIDMismatch) {
+		t.Errorf("third error = %v, want ErrResponseIDMismatch for missing response", err)
+	}
+}
+
+func TestSendBatchSharesRequestHeaders(t *testing.T) {
+	var header http.Header
+	server := newBatchServer(t, &header, func(calls []rpcCall) interface{} {
+		return []map[string]interface{}{{"id": calls[0].ID, "result": map[string]interface{}{}}}
+	})
+	client := newTestClient(t, server.URL, func(config *ClientConfig) {
+		config.Language = LanguageRu
+		config.SignRequest = func(body []byte) http.Header {
+			return http.Header{"X-Signature": []string{"signed"}}
+		}
+	})
+
+	_, err := client.NewBatch().Add("1", "receipts.get", nil).SendBatch(context.Background())
+	if err != nil {
+		t.Fatalf("SendBatch error: %v", err)
+	}
+
+	for name, want := range map[string]string{
+		"X-Auth":          "5e730e8e0b852a417aa49ceb:test-key",
+		"Content-Type":    "application/json",
+		"User-Agent":      "payme-go/" + Version,
+		"Accept-Language": LanguageRu,
+		"X-Signature":     "signed",
+	} {
+		if got := header.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestSendBatchNonJSONResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("Bad Gateway"))
+	}))
+	t.Cleanup(server.Close)
+	client := newTestClient(t, server.URL)
+
+	_, err := client.NewBatch().
+		Add("first", "receipts.get", map[string]interface{}{"id": "receipt-1"}).
+		SendBatch(context.Background())
+	if !errors.Is(err, ErrNonJSONResponse) {
+		t.Fatalf("error = %v, want ErrNonJSONResponse", err)
+	}
+	if !strings.Contains(err.Error(), "Bad Gateway") {
+		t.Errorf("error = %v, want body snippet", err)
+	}
+}
+
+func TestSendBatchCallsContainJSONRPCVersion(t *testing.T) {
+	var versions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var calls []map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&calls); err != nil {
+			t.Errorf("request decode error: %v", err)
+		}
+		for _, call := range calls {
+			version, _ := call["jsonrpc"].(string)
+			versions = append(versions, version)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	t.Cleanup(server.Close)
+	client := newTestClient(t, server.URL)
+
+	_, err := client.NewBatch().
+		Add("first", "receipts.get", map[string]interface{}{"id": "receipt-1"}).
+		Add("second", "receipts.get", map[string]interface{}{"id": "receipt-2"}).
+		SendBatch(context.Background())
+	if err != nil {
+		t.Fatalf("SendBatch error: %v", err)
+	}
+	if len(versions) != 2 || versions[0] != "2.0" || versions[1] != "2.0" {
+		t.Errorf("jsonrpc versions = %q, want 2.0 for every call", versions)
+	}
+}
+
+func TestSendBatchResponseTooLarge(t *testing.T) {
+	var header http.Header
+	server := newBatchServer(t, &header, func(calls []rpcCall) interface{} {
+		return []map[string]interface{}{{"id": calls[0].ID, "result": strings.Repeat("x", 1024)}}
+	})
+	client := newTestClient(t, server.URL, func(config *ClientConfig) {
+		config.MaxResponseBytes = 256
+	})
+
+	_, err := client.NewBatch().Add("1", "receipts.get", nil).SendBatch(context.Background())
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("error = %v, want ErrResponseTooLarge", err)
+	}
+}
+
+// recordingMetrics is a MetricsCollector recording observed methods and errors.
+type recordingMetrics struct {
+	methods []string
+	errs    []error
+}
+
+func (m *recordingMetrics) ObserveCall(method string, _ time.Duration, err error) {
+	m.methods = append(m.methods, method)
+	m.errs = append(m.errs, err)
+}
+
+func TestSendBatchIsInstrumented(t *testing.T) {
+	var header http.Header
+	server := newBatchServer(t, &header, func(calls []rpcCall) interface{} {
+		return []map[string]interface{}{
+			{"id": calls[0].ID, "result": receiptResult(Receipt{ID: "receipt-1"})},
+			{"id": calls[1].ID, "result": receiptResult(Receipt{ID: "receipt-2"})},
+		}
+	})
+
+	tracer := &recordingTracer{}
+	metrics := &recordingMetrics{}
+	var logs bytes.Buffer
+	client := newTestClient(t, server.URL, func(config *ClientConfig) {
+		config.TracerProvider = tracer
+		config.Metrics = metrics
+		config.SlogLogger = slog.New(slog.NewJSONHandler(&logs, nil))
+	})
+
+	_, err := client.NewBatch().
+		Add("first", "receipts.get", map[string]interface{}{"id": "receipt-1"}).
+		Add("second", "receipts.get", map[string]interface{}{"id": "receipt-2"}).
+		SendBatch(context.Background())
+	if err != nil {
+		t.Fatalf("SendBatch error: %v", err)
+	}
+
+	if len(tracer.spans) != 1 {
+		t.Fatalf("spans = %d, want 1", len(tracer.spans))
+	}
+	span := tracer.spans[0]
+	if span.name != "payme.batch" || !span.ended {
+		t.Errorf("span = %s ended %v, want ended payme.batch", span.name, span.ended)
+	}
+	if span.attributes["payme.batch_size"] != 2 || span.attributes["http.status_code"] != http.StatusOK {
+		t.Errorf("span attributes = %v, want batch size and status code", span.attributes)
+	}
+
+	if len(metrics.methods) != 1 || metrics.methods[0] != "batch" || metrics.errs[0] != nil {
+		t.Errorf("observed calls = %v %v, want one successful batch", metrics.methods, metrics.errs)
+	}
+
+	if !strings.Contains(logs.String(), `"method":"batch"`) || !strings.Contains(logs.String(), `"request_id":"first,second"`) {
+		t.Errorf("logs = %s, want batch record with call ids", logs.String())
+	}
+
+	if got := client.LastRequestID(); got != "first,second" {
+		t.Errorf("LastRequestID = %q, want first,second", got)
+	}
+}
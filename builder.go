@@ -0,0 +1,177 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ReceiptBuilder builds receipts.create parameters with fluent methods.
+// It is a readable alternative to CreateReceipt positional arguments.
+// A ReceiptBuilder is not safe for concurrent use.
+type ReceiptBuilder struct {
+	client      *Client
+	amount      int64
+	account     map[string]interface{}
+	description string
+	detail      *ReceiptDetailInput
+	currency    int
+	alpha       string
+	expireAt    time.Time
+
+	skipItemsCheck bool
+}
+
+// ReceiptParams contains the built receipts.create parameters.
+type ReceiptParams struct {
+	Amount      int64                  `json:"amount"`
+	Account     map[string]interface{} `json:"account"`
+	Description string                 `json:"description,omitempty"`
+	Detail      *ReceiptDetailInput    `json:"detail,omitempty"`
+	Currency    int                    `json:"currency,omitempty"`
+	// expire time in milliseconds after which the unpaid receipt expires
+	ExpireTime int64 `json:"expire_time,omitempty"`
+}
+
+// NewReceiptBuilder creates a new receipt builder bound to the client.
+// Returns a pointer to ReceiptBuilder.
+func (c *Client) NewReceiptBuilder() *ReceiptBuilder {
+	return &ReceiptBuilder{
+		client:  c,
+		account: make(map[string]interface{}),
+	}
+}
+
+// Amount sets the receipt amount in tiyin.
+func (b *ReceiptBuilder) Amount(amount int64) *ReceiptBuilder {
+	b.amount = amount
+	return b
+}
+
+// Account sets an account field of the receipt.
+func (b *ReceiptBuilder) Account(key string, value interface{}) *ReceiptBuilder {
+	b.account[key] = value
+	return b
+}
+
+// Description sets the receipt description.
+func (b *ReceiptBuilder) Description(description string) *ReceiptBuilder {
+	b.description = description
+	return b
+}
+
+// Currency sets the receipt currency code like CurrencyUSD, default is UZS.
+func (b *ReceiptBuilder) Currency(currency int) *ReceiptBuilder {
+	b.currency = currency
+	b.alpha = ""
+	return b
+}
+
+// CurrencyAlpha sets the receipt currency by ISO 4217 alpha code like "USD".
+// An unsupported code is reported by Build.
+func (b *ReceiptBuilder) CurrencyAlpha(alpha string) *ReceiptBuilder {
+	code, ok := CurrencyCodeFromAlpha(alpha)
+	b.currency = code
+	b.alpha = ""
+	if !ok {
+		b.alpha = alpha
+	}
+	return b
+}
+
+// ExpireAt sets the time after which the unpaid receipt expires, default PayMe receipt lifetime.
+// The time must be in the future when the receipt is built.
+func (b *ReceiptBuilder) ExpireAt(t time.Time) *ReceiptBuilder {
+	b.expireAt = t
+	return b
+}
+
+// Detail sets the fiscal detail of the receipt, replacing previously added items.
+func (b *ReceiptBuilder) Detail(detail ReceiptDetailInput) *ReceiptBuilder {
+	b.detail = &detail
+	return b
+}
+
+// AddItem adds a fiscal line item to the receipt detail.
+func (b *ReceiptBuilder) AddItem(item ReceiptItem) *ReceiptBuilder {
+	if b.detail == nil {
+		b.detail = &ReceiptDetailInput{}
+	}
+	b.detail.Items = append(b.detail.Items, item)
+	return b
+}
+
+// SkipItemsCheck disables checking that the items total equals the receipt amount.
+func (b *ReceiptBuilder) SkipItemsCheck() *ReceiptBuilder {
+	b.skipItemsCheck = true
+	return b
+}
+
+// Build validates the receipt and returns its parameters.
+// If items are added, their total with shipping must equal the amount unless SkipItemsCheck is set.
+// The client DefaultAccount is merged into the account, and amount and account checks
+// are skipped like in CreateReceipt if the client has SkipValidation set.
+// Returns ReceiptParams or an error if amount, account, expire time or items total is invalid.
+func (b *ReceiptBuilder) Build() (*ReceiptParams, error) {
+	merged := b.client.mergeAccount(b.account)
+
+	// Validation
+	if err := b.client.validateAmount(b.amount); err != nil {
+		return nil, err
+	}
+	if len(merged) == 0 && !b.client.SkipValidation {
+		return nil, fmt.Errorf("account is empty: %w", ErrInvalidParams)
+	}
+	if err := b.client.validateAccount(merged); err != nil {
+		return nil, err
+	}
+	if b.alpha != "" {
+		return nil, fmt.Errorf("unsupported currency %q: %w", b.alpha, ErrInvalidParams)
+	}
+	if b.currency != 0 && !IsValidCurrency(b.currency) {
+		return nil, fmt.Errorf("unsupported currency %d: %w", b.currency, ErrInvalidParams)
+	}
+	if !b.expireAt.IsZero() && !b.expireAt.After(b.client.now()) {
+		return nil, fmt.Errorf("expire time %s is not in the future: %w", b.expireAt.Format(time.RFC3339), ErrInvalidParams)
+	}
+	if b.detail != nil && len(b.detail.Items) > 0 && !b.skipItemsCheck {
+		if total := b.detail.Total(); total != b.amount {
+			return nil, fmt.Errorf("items total %d differs from amount %d by %d: %w", total, b.amount, total-b.amount, ErrInvalidParams)
+		}
+	}
+
+	account := make(map[string]interface{}, len(merged))
+	for key, value := range merged {
+		account[key] = value
+	}
+
+	var expireTime int64
+	if !b.expireAt.IsZero() {
+		expireTime = b.expireAt.UnixMilli()
+	}
+
+	return &ReceiptParams{
+		Amount:      b.amount,
+		Account:     account,
+		Description: b.description,
+		Detail:      b.detail,
+		Currency:    b.currency,
+		ExpireTime:  expireTime,
+	}, nil
+}
+
+// Create builds the receipt and sends it to receipts.create method.
+// Returns CreateReceiptResponse with receipt details or an error.
+func (b *ReceiptBuilder) Create(ctx context.Context, opts ...RequestOption) (*CreateReceiptResponse, error) {
+	params, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	var detail interface{}
+	if params.Detail != nil {
+		detail = params.Detail
+	}
+
+	return b.client.createReceipt(ctx, params.Amount, params.Account, params.Description, detail, params.Currency, params.ExpireTime, opts...)
+}
@@ -0,0 +1,192 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestReceiptBuilderAccountValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(*ClientConfig)
+		account   map[string]interface{}
+		amount    int64
+		want      map[string]interface{}
+		wantErr   error
+	}{
+		{
+			name:      "default account only",
+			configure: func(config *ClientConfig) { config.DefaultAccount = map[string]interface{}{"id": "merchant-1"} },
+			amount:    500000,
+			want:      map[string]interface{}{"id": "merchant-1"},
+		},
+		{
+			name: "account overrides default account",
+			configure: func(config *ClientConfig) {
+				config.DefaultAccount = map[string]interface{}{"id": "merchant-1", "branch": "7"}
+			},
+			account: map[string]interface{}{"id": "order-1"},
+			amount:  500000,
+			want:    map[string]interface{}{"id": "order-1", "branch": "7"},
+		},
+		{
+			name:    "empty account",
+			amount:  500000,
+			wantErr: ErrInvalidParams,
+		},
+		{
+			name:    "missing requisite",
+			account: map[string]interface{}{"order_id": "1"},
+			amount:  500000,
+			wantErr: ErrInvalidParams,
+		},
+		{
+			name:      "skip validation",
+			configure: func(config *ClientConfig) { config.SkipValidation = true },
+			amount:    -1,
+			want:      map[string]interface{}{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configure := func(*ClientConfig) {}
+			if tt.configure != nil {
+				configure = tt.configure
+			}
+			client := newTestClient(t, "http://127.0.0.1", configure)
+
+			builder := client.NewReceiptBuilder().Amount(tt.amount)
+			for key, value := range tt.account {
+				builder.Account(key, value)
+			}
+
+			params, err := builder.Build()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Build error: %v", err)
+			}
+			if fmt.Sprint(params.Account) != fmt.Sprint(tt.want) {
+				t.Errorf("account = %v, want %v", params.Account, tt.want)
+			}
+		})
+	}
+}
+
+func TestReceiptBuilderExpireAt(t *testing.T) {
+	now := time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		expireAt time.Time
+		want     int64
+		wantErr  error
+	}{
+		{"not set", time.Time{}, 0, nil},
+		{"future", now.Add(30 * time.Minute), now.Add(30 * time.Minute).UnixMilli(), nil},
+		{"now", now, 0, ErrInvalidParams},
+		{"past", now.Add(-time.Minute), 0, ErrInvalidParams},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, "http://127.0.0.1", func(config *ClientConfig) {
+				config.Clock = fixedClock(now)
+			})
+
+			params, err := client.NewReceiptBuilder().
+				Amount(500000).
+				Account("id", "order-1").
+				ExpireAt(tt.expireAt).
+				Build()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Build error: %v", err)
+			}
+			if params.ExpireTime != tt.want {
+				t.Errorf("ExpireTime = %d, want %d", params.ExpireTime, tt.want)
+			}
+		})
+	}
+}
+
+func TestReceiptBuilderCreateSendsExpireTime(t *testing.T) {
+	expireAt := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+
+	var params []map[string]interface{}
+	server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+		params = append(params, call.Params)
+		return receiptResult(Receipt{ID: "receipt-1"}), nil
+	})
+	client := newTestClient(t, server.URL)
+
+	for _, at := range []time.Time{expireAt, {}} {
+		_, err := client.NewReceiptBuilder().
+			Amount(500000).
+			Account("id", "order-1").
+			ExpireAt(at).
+			Create(context.Background())
+		if err != nil {
+			t.Fatalf("Create error: %v", err)
+		}
+	}
+
+	if got := params[0]["expire_time"]; got != float64(expireAt.UnixMilli()) {
+		t.Errorf("expire_time = %v, want %d", got, expireAt.UnixMilli())
+	}
+	if _, ok := params[1]["expire_time"]; ok {
+		t.Errorf("expire_time = %v, want omitted", params[1]["expire_time"])
+	}
+}
+
+func TestReceiptBuilderCurrencyAlpha(t *testing.T) {
+	tests := []struct {
+		name    string
+		build   func(b *ReceiptBuilder) *ReceiptBuilder
+		want    int
+		wantErr bool
+	}{
+		{"alpha", func(b *ReceiptBuilder) *ReceiptBuilder { return b.CurrencyAlpha("USD") }, CurrencyUSD, false},
+		{"lowercase alpha", func(b *ReceiptBuilder) *ReceiptBuilder { return b.CurrencyAlpha("gbp") }, CurrencyGBP, false},
+		{"unsupported alpha", func(b *ReceiptBuilder) *ReceiptBuilder { return b.CurrencyAlpha("JPY") }, 0, true},
+		{"numeric code replaces unsupported alpha", func(b *ReceiptBuilder) *ReceiptBuilder {
+			return b.CurrencyAlpha("JPY").Currency(CurrencyEUR)
+		}, CurrencyEUR, false},
+		{"alpha replaces numeric code", func(b *ReceiptBuilder) *ReceiptBuilder {
+			return b.Currency(CurrencyEUR).CurrencyAlpha("RUB")
+		}, CurrencyRUB, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, "http://127.0.0.1")
+
+			params, err := tt.build(client.NewReceiptBuilder().Amount(500000).Account("id", "order-1")).Build()
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidParams) {
+					t.Errorf("error = %v, want ErrInvalidParams", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Build error: %v", err)
+			}
+			if params.Currency != tt.want {
+				t.Errorf("Currency = %d, want %d", params.Currency, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CreateCard registers a new card in PayMe system and obtains its token.
+// It validates card number and expire date, then sends a request to cards.create method.
+// Returns CreateCardResponse with card details or an error.
+func (c *Client) CreateCard(ctx context.Context, cardNumber, expire string, save bool, opts ...RequestOption) (*CreateCardResponse, error) {
+	// Validation
+	if err := c.validateCardNumber(cardNumber); err != nil {
+		return nil, err
+	}
+	if err := c.validateCardExpiry(expire); err != nil {
+		return nil, err
+	}
+
+	requestID := c.newRequestID("CardsCreate")
+
+	cardParams := map[string]interface{}{
+		"card": map[string]interface{}{
+			"number": cardNumber,
+			"expire": expire,
+		},
+		"save": save,
+	}
+
+	resp, err := c.sendRequest(ctx, requestID, "cards.create", cardParams, true, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse result
+	var result CreateCardResponse
+	if resp.Result != nil {
+		resultBytes, _ := json.Marshal(resp.Result)
+		if err := json.Unmarshal(resultBytes, &result); err != nil {
+			return nil, fmt.Errorf("result unmarshal error: %w", err)
+		}
+	}
+
+	return &result, nil
+}
+
+// GetCardVerifyCode requests an SMS verification code for the card.
+// It validates card token and sends a request to cards.get_verify_code method.
+// Returns GetVerifyCodeResponse with phone mask and wait time or an error.
+func (c *Client) GetCardVerifyCode(ctx context.Context, token string, opts ...RequestOption) (*GetVerifyCodeResponse, error) {
+	// Validation
+	if err := c.validateCardToken(token); err != nil {
+		return nil, err
+	}
+
+	requestID := c.newRequestID("CardsGetVerifyCode")
+
+	cardParams := map[string]interface{}{
+		"token": token,
+	}
+
+	resp, err := c.sendRequest(ctx, requestID, "cards.get_verify_code", cardParams, true, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse result
+	var result GetVerifyCodeResponse
+	if resp.Result != nil {
+		resultBytes, _ := json.Marshal(resp.Result)
+		if err := json.Unmarshal(resultBytes, &result); err != nil {
+			return nil, fmt.Errorf("result unmarshal error: %w", err)
+		}
+	}
+
+	return &result, nil
+}
+
+// VerifyCard confirms the card with the SMS verification code.
+// It validates card token and code, then sends a request to cards.verify method.
+// Returns VerifyCardResponse with verified card details or an error.
+func (c *Client) VerifyCard(ctx context.Context, token, code string, opts ...RequestOption) (*VerifyCardResponse, error) {
+	// Validation
+	if err := c.validateCardToken(token); err != nil {
+		return nil, err
+	}
+	if err := c.validateVerifyCode(code); err != nil {
+		return nil, err
+	}
+
+	requestID := c.newRequestID("CardsVerify")
+
+	cardParams := map[string]interface{}{
+		"token": token,
+		"code":  code,
+	}
+
+	resp, err := c.sendRequest(ctx, requestID, "cards.verify", cardParams, true, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse result
+	var result VerifyCardResponse
+	if resp.Result != nil {
+		resultBytes, _ := json.Marshal(resp.Result)
+		if err := json.Unmarshal(resultBytes, &result); err != nil {
+			return nil, fmt.Errorf("result unmarshal error: %w", err)
+		}
+	}
+
+	return &result, nil
+}
+
+// CheckCard checks the status of an existing card token.
+// It validates card token and sends a request to cards.check method.
+// Returns CheckCardResponse with card verify status or an error.
+func (c *Client) CheckCard(ctx context.Context, token string, opts ...RequestOption) (*CheckCardResponse, error) {
+	// Validation
+	if err := c.validateCardToken(token); err != nil {
+		return nil, err
+	}
+
+	requestID := c.newRequestID("CardsCheck")
+
+	cardParams := map[string]interface{}{
+		"token": token,
+	}
+
+	resp, err := c.sendRequest(ctx, requestID, "cards.check", cardParams, false, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse result
+	var result CheckCardResponse
+	if resp.Result != nil {
+		resultBytes, _ := json.Marshal(resp.Result)
+		if err := json.Unmarshal(resultBytes, &result); err != nil {
+			return nil, fmt.Errorf("result unmarshal error: %w", err)
+		}
+	}
+
+	return &result, nil
+}
+
+// RemoveCard removes a saved card token from PayMe system.
+// It validates card token and sends a request to cards.remove method.
+// If WithIgnoreNotFound option is passed, a "card not found" response is treated as successful removal.
+// Returns RemoveCardResponse with removal status or an error.
+func (c *Client) RemoveCard(ctx context.Context, token string, opts ...RequestOption) (*RemoveCardResponse, error) {
+	// Validation
+	if err := c.validateCardToken(token); err != nil {
+		return nil, err
+	}
+
+	requestID := c.newRequestID("CardsRemove")
+
+	cardParams := map[string]interface{}{
+		"token": token,
+	}
+
+	resp, err := c.sendRequest(ctx, requestID, "cards.remove", cardParams, false, opts...)
+	if err != nil {
+		if errors.Is(err, ErrCardNotFound) || errors.Is(err, ErrCardNumberNotFound) {
+			if applyOptions(opts).ignoreNotFound {
+				return &RemoveCardResponse{Success: true}, nil
+			}
+			return nil, fmt.Errorf("card remove error: %w", err)
+		}
+		return nil, err
+	}
+
+	// Parse result
+	var result RemoveCardResponse
+	if resp.Result != nil {
+		resultBytes, _ := json.Marshal(resp.Result)
+		if err := json.Unmarshal(resultBytes, &result); err != nil {
+			return nil, fmt.Errorf("result unmarshal error: %w", err)
+		}
+	}
+
+	return &result, nil
+}
+
+// FormattedExpire returns the card expiry in MM/YY format.
+// Returns the raw Expire value if it cannot be parsed.
+func (card *Card) FormattedExpire() string {
+	month, year, err := ParseCardExpiry(card.Expire)
+	if err != nil {
+		return card.Expire
+	}
+	return fmt.Sprintf("%02d/%02d", month, year%100)
+}
+
+// MaskedNumber returns the card number with hidden middle digits like 8600****1234.
+// Numbers already masked by PayMe are returned as is.
+func (card *Card) MaskedNumber() string {
+	if strings.Contains(card.Number, "*") {
+		return card.Number
+	}
+	return MaskCardNumber(card.Number)
+}
+
+// IsUsable checks if the card is verified and not expired.
+// Returns true if the card can be used for payments, false otherwise.
+func (card *Card) IsUsable() bool {
+	return card.Verify && !IsCardExpired(card.Expire)
+}
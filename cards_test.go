@@ -0,0 +1,177 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCreateCardRejectsInvalidNumberWithoutRequest(t *testing.T) {
+	server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+		t.Errorf("unexpected %s request", call.Method)
+		return nil, nil
+	})
+	client := newTestClient(t, server.URL)
+
+	_, err := client.CreateCard(context.Background(), "8600069195406312", "0399", false)
+	if !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("error = %v, want ErrInvalidParams", err)
+	}
+}
+
+func TestRemoveCardIgnoresCardNumberNotFound(t *testing.T) {
+	server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+		return nil, &Error{Code: CardNumberNotFoundCode, Message: "card not found"}
+	})
+	client := newTestClient(t, server.URL)
+
+	resp, err := client.RemoveCard(context.Background(), "card-token-123", WithIgnoreNotFound())
+	if err != nil {
+		t.Fatalf("RemoveCard error: %v", err)
+	}
+	if !resp.Success {
+		t.Error("Success = false, want true")
+	}
+
+	_, err = client.RemoveCard(context.Background(), "card-token-123")
+	if !errors.Is(err, ErrCardNumberNotFound) {
+		t.Errorf("error = %v, want ErrCardNumberNotFound", err)
+	}
+}
+
+func TestCreateCardValidatesExpire(t *testing.T) {
+	now := time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		expire         string
+		skipValidation bool
+		wantErr        error
+	}{
+		{name: "valid", expire: "0325"},
+		{name: "expire month", expire: "03/24"},
+		{name: "expired", expire: "0224", wantErr: ErrCardExpired},
+		{name: "malformed", expire: "3/24", wantErr: ErrInvalidParams},
+		{name: "invalid month", expire: "1325", wantErr: ErrInvalidParams},
+		{name: "skip validation", expire: "0224", skipValidation: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+				calls++
+				return map[string]interface{}{"card": Card{Token: "card-token-123"}}, nil
+			})
+			client := newTestClient(t, server.URL, func(config *ClientConfig) {
+				config.Clock = fixedClock(now)
+				config.SkipValidation = tt.skipValidation
+			})
+
+			_, err := client.CreateCard(context.Background(), "8600069195406311", tt.expire, false)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("error = %v, want %v", err, tt.wantErr)
+				}
+				if tt.wantErr == ErrInvalidParams && errors.Is(err, ErrCardExpired) {
+					t.Errorf("error = %v, malformed expire must not be ErrCardExpired", err)
+				}
+				if calls != 0 {
+					t.Errorf("calls = %d, want no request", calls)
+				}
+				return
+			}
+			if err != nil || calls != 1 {
+				t.Errorf("CreateCard = %v with %d calls, want one request", err, calls)
+			}
+		})
+	}
+}
+
+func TestVerifyCardValidatesCode(t *testing.T) {
+	var calls int
+	server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+		calls++
+		return map[string]interface{}{"card": Card{Token: "card-token-123", Verify: true}}, nil
+	})
+	client := newTestClient(t, server.URL)
+
+	if _, err := client.VerifyCard(context.Background(), "card-token-123", "12ab"); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("error = %v, want ErrInvalidParams", err)
+	}
+	if calls != 0 {
+		t.Errorf("calls = %d, want no request for invalid code", calls)
+	}
+
+	skipping := newTestClient(t, server.URL, func(config *ClientConfig) {
+		config.SkipValidation = true
+	})
+	if _, err := skipping.VerifyCard(context.Background(), "card-token-123", "12ab"); err != nil {
+		t.Errorf("VerifyCard with SkipValidation error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1 with SkipValidation", calls)
+	}
+}
+
+func TestCardFormattedExpire(t *testing.T) {
+	tests := []struct {
+		expire string
+		want   string
+	}{
+		{"0327", "03/27"},
+		{"1230", "12/30"},
+		{"03/27", "03/27"},
+		{"03", "03"},     // 2 digits, returned as is
+		{"1327", "1327"}, // invalid month
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		card := Card{Expire: tt.expire}
+		if got := card.FormattedExpire(); got != tt.want {
+			t.Errorf("FormattedExpire(%q) = %q, want %q", tt.expire, got, tt.want)
+		}
+	}
+}
+
+func TestCardMaskedNumber(t *testing.T) {
+	tests := []struct {
+		number string
+		want   string
+	}{
+		{"8600069195406311", "8600****6311"},
+		{"860006******6311", "860006******6311"},
+		{"8600", "****"},
+	}
+
+	for _, tt := range tests {
+		card := Card{Number: tt.number}
+		if got := card.MaskedNumber(); got != tt.want {
+			t.Errorf("MaskedNumber(%q) = %q, want %q", tt.number, got, tt.want)
+		}
+	}
+}
+
+func TestCardIsUsable(t *testing.T) {
+	future := fmt.Sprintf("12%02d", (time.Now().Year()+2)%100)
+
+	tests := []struct {
+		name string
+		card Card
+		want bool
+	}{
+		{"verified", Card{Expire: future, Verify: true}, true},
+		{"not verified", Card{Expire: future}, false},
+		{"expired", Card{Expire: "0120", Verify: true}, false},
+		{"invalid expire", Card{Expire: "abcd", Verify: true}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.card.IsUsable(); got != tt.want {
+			t.Errorf("%s: IsUsable = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
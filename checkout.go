@@ -0,0 +1,128 @@
+package payment
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	TestCheckoutHost       = "https://checkout.test.paycom.uz"
+	ProductionCheckoutHost = "https://checkout.paycom.uz"
+)
+
+const (
+	LanguageUz = "uz"
+	LanguageRu = "ru"
+	LanguageEn = "en"
+)
+
+// IsValidLanguage checks if the language is supported by PayMe.
+// Returns true for uz, ru and en.
+func IsValidLanguage(language string) bool {
+	switch language {
+	case LanguageUz, LanguageRu, LanguageEn:
+		return true
+	default:
+		return false
+	}
+}
+
+// CheckoutOptions contains optional parameters of the hosted checkout URL.
+type CheckoutOptions struct {
+	// use the test checkout host
+	TestMode bool
+	// checkout page language like LanguageUz, LanguageRu or LanguageEn
+	Language string
+	// url the user is redirected to after payment
+	CallbackURL string
+	// redirect delay after payment in milliseconds
+	CallbackTimeout int
+	// currency code like CurrencyUZS
+	Currency int
+}
+
+// GenerateCheckoutURL builds the hosted checkout URL to redirect the user to.
+// It joins m=<merchant id>;ac.<key>=<value>;a=<amount in tiyin> and the options with ";",
+// base64 encodes the result and appends it to the test or production checkout host.
+// The format has no escaping, so account keys and values containing ";" or "=" are rejected.
+// Returns the checkout URL, or ErrInvalidParams if a value contains a separator.
+func GenerateCheckoutURL(merchantID string, amount int64, account map[string]string, opts CheckoutOptions) (string, error) {
+	// Validation
+	if err := validateCheckoutValue("merchant id", merchantID); err != nil {
+		return "", err
+	}
+	// Callback url query values may contain "=", the value ends at the next ";"
+	if strings.Contains(opts.CallbackURL, ";") {
+		return "", fmt.Errorf("callback url %q contains \";\": %w", opts.CallbackURL, ErrInvalidParams)
+	}
+
+	params := []string{"m=" + merchantID}
+
+	// Sort account keys for a stable URL
+	keys := make([]string, 0, len(account))
+	for key := range account {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := validateCheckoutValue("account key", key); err != nil {
+			return "", err
+		}
+		if err := validateCheckoutValue("account "+key, account[key]); err != nil {
+			return "", err
+		}
+		params = append(params, fmt.Sprintf("ac.%s=%s", key, account[key]))
+	}
+
+	params = append(params, fmt.Sprintf("a=%d", amount))
+
+	if opts.Language != "" {
+		params = append(params, "l="+opts.Language)
+	}
+	if opts.CallbackURL != "" {
+		params = append(params, "c="+opts.CallbackURL)
+	}
+	if opts.CallbackTimeout > 0 {
+		params = append(params, fmt.Sprintf("ct=%d", opts.CallbackTimeout))
+	}
+	if opts.Currency != 0 {
+		params = append(params, fmt.Sprintf("cr=%d", opts.Currency))
+	}
+
+	host := ProductionCheckoutHost
+	if opts.TestMode {
+		host = TestCheckoutHost
+	}
+
+	return host + "/" + base64.StdEncoding.EncodeToString([]byte(strings.Join(params, ";"))), nil
+}
+
+// validateCheckoutValue checks that the value doesn't contain the checkout separators.
+// Returns ErrInvalidParams if the value contains ";" or "=".
+func validateCheckoutValue(name, value string) error {
+	if strings.ContainsAny(value, ";=") {
+		return fmt.Errorf("%s %q contains \";\" or \"=\": %w", name, value, ErrInvalidParams)
+	}
+	return nil
+}
+
+// CheckoutURL builds the hosted checkout URL for the client merchant.
+// Test mode and language are taken from the client unless set in opts.
+// Returns the checkout URL, or ErrInvalidParams if the language is unsupported or a value contains a separator.
+func (c *Client) CheckoutURL(amount int64, account map[string]string, opts CheckoutOptions) (string, error) {
+	if c.IsTestMode {
+		opts.TestMode = true
+	}
+	if opts.Language == "" {
+		opts.Language = c.Language
+	}
+
+	// Validation
+	if opts.Language != "" && !IsValidLanguage(opts.Language) {
+		return "", fmt.Errorf("unsupported language %q: %w", opts.Language, ErrInvalidParams)
+	}
+
+	return GenerateCheckoutURL(c.Headers.paymeID, amount, account, opts)
+}
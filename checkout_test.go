@@ -0,0 +1,98 @@
+package payment
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// decodeCheckoutURL returns the host and the decoded parameters of the checkout URL.
+func decodeCheckoutURL(t *testing.T, checkoutURL string) (string, map[string]string) {
+	t.Helper()
+
+	slash := strings.LastIndex(checkoutURL, "/")
+	decoded, err := base64.StdEncoding.DecodeString(checkoutURL[slash+1:])
+	if err != nil {
+		t.Fatalf("checkout params decode error: %v", err)
+	}
+
+	params := make(map[string]string)
+	for _, param := range strings.Split(string(decoded), ";") {
+		key, value, ok := strings.Cut(param, "=")
+		if !ok {
+			t.Fatalf("checkout param %q has no value", param)
+		}
+		if _, dup := params[key]; dup {
+			t.Fatalf("checkout param %q is duplicated", key)
+		}
+		params[key] = value
+	}
+	return checkoutURL[:slash], params
+}
+
+func TestGenerateCheckoutURL(t *testing.T) {
+	checkoutURL, err := GenerateCheckoutURL("5e730e8e0b852a417aa49ceb", 150000, map[string]string{"order_id": "42", "user": "7"}, CheckoutOptions{
+		TestMode:        true,
+		Language:        LanguageRu,
+		CallbackURL:     "https://shop.uz/orders?id=42",
+		CallbackTimeout: 15000,
+		Currency:        CurrencyUZS,
+	})
+	if err != nil {
+		t.Fatalf("GenerateCheckoutURL error: %v", err)
+	}
+
+	host, params := decodeCheckoutURL(t, checkoutURL)
+	if host != "https://checkout.test.paycom.uz" {
+		t.Errorf("host = %q, want the test checkout host", host)
+	}
+
+	want := map[string]string{
+		"m":           "5e730e8e0b852a417aa49ceb",
+		"ac.order_id": "42",
+		"ac.user":     "7",
+		"a":           "150000",
+		"l":           "ru",
+		"c":           "https://shop.uz/orders?id=42",
+		"ct":          "15000",
+		"cr":          "860",
+	}
+	if len(params) != len(want) {
+		t.Errorf("params = %v, want %v", params, want)
+	}
+	for key, value := range want {
+		if params[key] != value {
+			t.Errorf("param %s = %q, want %q", key, params[key], value)
+		}
+	}
+
+	productionURL, err := GenerateCheckoutURL("5e730e8e0b852a417aa49ceb", 100, nil, CheckoutOptions{})
+	if err != nil {
+		t.Fatalf("GenerateCheckoutURL error: %v", err)
+	}
+	if host, _ := decodeCheckoutURL(t, productionURL); host != ProductionCheckoutHost {
+		t.Errorf("host = %q, want %q", host, ProductionCheckoutHost)
+	}
+}
+
+func TestGenerateCheckoutURLRejectsSeparators(t *testing.T) {
+	tests := []struct {
+		name    string
+		account map[string]string
+		opts    CheckoutOptions
+	}{
+		{name: "semicolon in value", account: map[string]string{"order_id": "42;a=1"}},
+		{name: "equals in value", account: map[string]string{"order_id": "42=1"}},
+		{name: "separator in key", account: map[string]string{"order;id": "42"}},
+		{name: "semicolon in callback", opts: CheckoutOptions{CallbackURL: "https://shop.uz/;a=1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := GenerateCheckoutURL("5e730e8e0b852a417aa49ceb", 100, tt.account, tt.opts); !errors.Is(err, ErrInvalidParams) {
+				t.Errorf("error = %v, want ErrInvalidParams", err)
+			}
+		})
+	}
+}
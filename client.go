@@ -2,34 +2,109 @@ package payment
 
 import (
 	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
+	"math/rand"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Client is the main struct for interacting with PayMe API.
 // This struct handles all PayMe API method calls, HTTP requests,
 // authentication, and response parsing.
+//
+// A Client is safe for concurrent use by multiple goroutines and should be shared
+// across request handlers. Mutable shared state (last request id, closed flag,
+// idempotency cache, rate limiter, metrics) is protected by mutexes. Exported fields
+// are configuration and must not be modified after the client is in use.
 type Client struct {
 	// headers for authentication
 	Headers xAuthHeaders
-	// base url
+	// base url, used if SubscribeURL is empty
 	BaseURL string
+	// Subscribe API url
+	SubscribeURL string
 	// http client
 	HTTPClient http.Client
 	// logger
 	Logger *log.Logger
+	// structured logger
+	SlogLogger *slog.Logger
+	// redactor for log output
+	Redactor *Redactor
 	// timeout
 	Timeout time.Duration
 	// is test mode
 	IsTestMode bool
 	// requisite name like charge_id, order_id, id you given to requisite title in payme dashboard
 	RequisiteName string
+	// skip checking account contains requisite name
+	SkipAccountValidation bool
+	// skip all client-side validation of amounts, ids, tokens and accounts
+	SkipValidation bool
+	// accept responses with id different from request id
+	AllowResponseIDMismatch bool
+	// max retries on transient failures
+	MaxRetries int
+	// base backoff between retries
+	RetryBackoff time.Duration
+	// cache for PayReceiptWithKey idempotency keys
+	IdempotencyCache IdempotencyCache
+	// rate limiter for outgoing requests
+	RateLimiter RateLimiter
+	// hook called with raw request body before sending
+	RequestHook func(method string, body []byte)
+	// hook called with raw response body after receiving
+	ResponseHook func(method string, body []byte, err error)
+	// tracer for API call spans
+	Tracer Tracer
+	// metrics collector for API calls
+	Metrics MetricsCollector
+	// validate and log requests without sending them
+	DryRun bool
+	// synthetic results of DryRun requests by method name
+	DryRunResults map[string]interface{}
+	// language of PayMe messages and checkout page
+	Language string
+	// clock for request ids and time windows
+	Clock Clock
+	// cancel created receipt if payment of create and pay methods fails
+	CancelOnPayFailure bool
+	// jsonrpc version of outgoing requests, omitted if empty
+	JSONRPCVersion string
+	// account fields added to every receipt account
+	DefaultAccount map[string]interface{}
+	// max response body size in bytes
+	MaxResponseBytes int64
+	// hook returning signature headers computed from the request body
+	SignRequest func(body []byte) http.Header
+	// User-Agent header of requests
+	UserAgent string
+	// receipt account keys masked in log output
+	SensitiveAccountKeys []string
+	// description of merchant receipts formatted with the order id
+	DescriptionTemplate string
+
+	// request id of the last sent request
+	mu            sync.Mutex
+	lastRequestID string
+	closed        bool
+	// counter of request ids created by the client
+	requestSeq atomic.Uint64
 }
 
 // ClientConfig contains configuration parameters for creating a PayMe client.
@@ -44,14 +119,83 @@ type ClientConfig struct {
 	IsTestMode bool `json:"is_test_mode"`
 	// requisite name like charge_id, order_id, id you given to requisite title in payme dashboard
 	RequisiteName string `json:"requisite_name"`
+	// language of PayMe messages and checkout page: uz, ru or en, default PayMe language
+	Language string `json:"language"`
+	// clock for request ids and time windows, default system clock
+	Clock Clock `json:"-"`
+	// cancel created receipt if payment of CreateAndPayReceipt or CreateAndPayMerchantReceipt fails
+	CancelOnPayFailure bool `json:"cancel_on_pay_failure"`
+	// jsonrpc version of outgoing requests for strict gateways, default 2.0
+	JSONRPCVersion string `json:"jsonrpc_version"`
+	// static account fields like reason or branch id merged into every receipt account,
+	// fields given to the call take precedence over default fields
+	DefaultAccount map[string]interface{} `json:"default_account"`
+	// max response body size in bytes, default 4 MB
+	MaxResponseBytes int64 `json:"max_response_bytes"`
+	// check connectivity and credentials with Ping in NewClient
+	PingOnCreate bool `json:"ping_on_create"`
+	// hook returning custom signature headers computed from the raw request body,
+	// it runs after the standard headers are set, so returned headers override them
+	SignRequest func(body []byte) http.Header `json:"-"`
+	// User-Agent header of requests, default payme-go/<version>
+	UserAgent string `json:"user_agent"`
+	// add the first characters of merchant id to the default User-Agent
+	UserAgentWithMerchant bool `json:"user_agent_with_merchant"`
+	// receipt account keys masked in log output, default DefaultSensitiveAccountKeys
+	SensitiveAccountKeys []string `json:"sensitive_account_keys"`
+	// description of merchant receipts with exactly one %s verb for the order id, default Description
+	DescriptionTemplate string `json:"description_template"`
+	// skip checking receipt account contains requisite name, for multi-requisite setups
+	SkipAccountValidation bool `json:"skip_account_validation"`
+	// skip client-side validation of amounts, receipt ids, card tokens and accounts, e.g. for short sandbox ids,
+	// invalid input is then sent to PayMe and fails with a PayMe error instead of ValidationError
+	SkipValidation bool `json:"skip_validation"`
+	// accept responses with id different from request id, for proxies that rewrite ids
+	AllowResponseIDMismatch bool `json:"allow_response_id_mismatch"`
 	// logger
 	Logger *log.Logger `json:"logger"`
-	// http client
+	// structured logger with method, request_id, receipt_id and error_code attributes
+	SlogLogger *slog.Logger `json:"-"`
+	// redactor masking card tokens and numbers in log output, default NewRedactor()
+	Redactor *Redactor `json:"-"`
+	// http client, a transport tuned for PayMe host is used if its Transport is nil
 	HTTPClient http.Client `json:"http_client"`
-	// base url
+	// max idle connections of the default transport, default 100
+	MaxIdleConns int `json:"max_idle_conns"`
+	// max idle connections per host of the default transport, default 100
+	MaxIdleConnsPerHost int `json:"max_idle_conns_per_host"`
+	// idle connection timeout of the default transport, default 90 seconds
+	IdleConnTimeout time.Duration `json:"idle_conn_timeout"`
+	// TLS config of the default transport, e.g. WithCertPinning, not applied to a custom transport
+	TLSConfig *tls.Config `json:"-"`
+	// base url, alias of SubscribeURL kept for backward compatibility
 	BaseURL string `json:"base_url"`
+	// Subscribe API url, default by test mode
+	SubscribeURL string `json:"subscribe_url"`
 	// timeout default 30 seconds
 	Timeout time.Duration `json:"timeout"`
+	// max retries on network errors, 5xx statuses and unavailable service, default 0
+	MaxRetries int `json:"max_retries"`
+	// base backoff between retries doubled on each attempt, default 500 milliseconds
+	RetryBackoff time.Duration `json:"retry_backoff"`
+	// idempotency cache for PayReceiptWithKey, default in-memory cache with 24 hours ttl and 10000 keys
+	IdempotencyCache IdempotencyCache `json:"-"`
+	// token bucket rate limit, no limit if RequestsPerSecond is 0
+	RateLimit RateLimit `json:"rate_limit"`
+	// custom rate limiter for outgoing requests, replaces the limiter created from RateLimit
+	RateLimiter RateLimiter `json:"-"`
+	// hook called with raw JSON-RPC request body before sending
+	RequestHook func(method string, body []byte) `json:"-"`
+	// hook called with raw JSON-RPC response body and error after receiving, body is nil on transport errors
+	ResponseHook func(method string, body []byte, err error) `json:"-"`
+	// tracer provider for API call spans, tracing is disabled if nil
+	TracerProvider TracerProvider `json:"-"`
+	// metrics collector for API calls, metrics are disabled if nil
+	Metrics MetricsCollector `json:"-"`
+	// validate and log requests without sending them to PayMe
+	DryRun bool `json:"dry_run"`
+	// synthetic results of DryRun requests by method name like receipts.create, default empty object
+	DryRunResults map[string]interface{} `json:"-"`
 }
 
 // xAuthHeaders contains authentication headers for PayMe API.
@@ -65,7 +209,7 @@ type xAuthHeaders struct {
 // It validates the config, sets default values, and initializes the client.
 // Returns a pointer to Client and any error that occurred during initialization.
 func NewClient(config ClientConfig) (*Client, error) {
-	err := config.validate()
+	err := config.Validate()
 	if err != nil {
 		return nil, err
 	}
@@ -80,13 +224,58 @@ func NewClient(config ClientConfig) (*Client, error) {
 		config.RequisiteName = "id"
 	}
 
-	// Default base URL based on test mode
-	if config.BaseURL == "" {
-		if config.IsTestMode {
-			config.BaseURL = TestEndpoint
-		} else {
-			config.BaseURL = ProductionEndpoint
-		}
+	// Default Subscribe API URL based on test mode
+	if config.SubscribeURL == "" {
+		config.SubscribeURL = config.BaseURL
+	}
+	if config.SubscribeURL == "" {
+		config.SubscribeURL = EndpointFor(config.IsTestMode)
+	}
+	config.BaseURL = config.SubscribeURL
+
+	// Default retry backoff
+	if config.RetryBackoff == 0 {
+		config.RetryBackoff = 500 * time.Millisecond
+	}
+
+	// Default sensitive account keys
+	if config.SensitiveAccountKeys == nil {
+		config.SensitiveAccountKeys = DefaultSensitiveAccountKeys
+	}
+
+	// Default merchant receipt description
+	if config.DescriptionTemplate == "" {
+		config.DescriptionTemplate = Description
+	}
+
+	// Default redactor masking sensitive account keys as well
+	if config.Redactor == nil {
+		config.Redactor = NewRedactor(config.SensitiveAccountKeys...)
+	}
+
+	// Default idempotency cache
+	if config.IdempotencyCache == nil {
+		config.IdempotencyCache = NewMemoryIdempotencyCache(24*time.Hour, 10000)
+	}
+
+	// Default max response body size
+	if config.MaxResponseBytes == 0 {
+		config.MaxResponseBytes = defaultMaxResponseBytes
+	}
+
+	// Default User-Agent
+	if config.UserAgent == "" {
+		config.UserAgent = defaultUserAgent(config.PaymeID, config.UserAgentWithMerchant)
+	}
+
+	// Default JSON-RPC version
+	if config.JSONRPCVersion == "" {
+		config.JSONRPCVersion = "2.0"
+	}
+
+	// Default clock
+	if config.Clock == nil {
+		config.Clock = systemClock{}
 	}
 
 	// Default HTTP client
@@ -94,31 +283,130 @@ func NewClient(config ClientConfig) (*Client, error) {
 		config.HTTPClient.Timeout = config.Timeout
 	}
 
+	// Default transport, custom transport of the HTTP client is used as is
+	if config.HTTPClient.Transport == nil {
+		config.HTTPClient.Transport = newTransport(config)
+	}
+
 	client := &Client{
 		HTTPClient:    config.HTTPClient,
 		BaseURL:       config.BaseURL,
+		SubscribeURL:  config.SubscribeURL,
 		Logger:        config.Logger,
+		SlogLogger:    config.SlogLogger,
+		Redactor:      config.Redactor,
 		Headers:       getXAuthHeaders(config.PaymeID, config.PaymeKey),
 		Timeout:       config.Timeout,
 		IsTestMode:    config.IsTestMode,
 		RequisiteName: config.RequisiteName,
+
+		SkipAccountValidation:   config.SkipAccountValidation,
+		SkipValidation:          config.SkipValidation,
+		AllowResponseIDMismatch: config.AllowResponseIDMismatch,
+		MaxRetries:              config.MaxRetries,
+		RetryBackoff:            config.RetryBackoff,
+
+		IdempotencyCache: config.IdempotencyCache,
+		RequestHook:      config.RequestHook,
+		ResponseHook:     config.ResponseHook,
+		Metrics:          config.Metrics,
+
+		DryRun:        config.DryRun,
+		DryRunResults: config.DryRunResults,
+		Language:      config.Language,
+		Clock:         config.Clock,
+
+		CancelOnPayFailure: config.CancelOnPayFailure,
+		JSONRPCVersion:     config.JSONRPCVersion,
+		DefaultAccount:     config.DefaultAccount,
+		MaxResponseBytes:   config.MaxResponseBytes,
+		SignRequest:        config.SignRequest,
+		UserAgent:          config.UserAgent,
+
+		SensitiveAccountKeys: config.SensitiveAccountKeys,
+		DescriptionTemplate:  config.DescriptionTemplate,
+	}
+
+	client.warnEnvironmentMismatch()
+
+	// Tracer
+	if config.TracerProvider != nil {
+		client.Tracer = config.TracerProvider.Tracer(TracerName)
+	}
+
+	// Rate limiter
+	switch {
+	case config.RateLimiter != nil:
+		client.RateLimiter = config.RateLimiter
+	case config.RateLimit.RequestsPerSecond > 0:
+		client.RateLimiter = NewTokenBucketLimiter(config.RateLimit.RequestsPerSecond, config.RateLimit.Burst)
+	}
+
+	// Check credentials, permission denied is returned as ErrEmptyOrInvalidPaycomKey
+	if config.PingOnCreate {
+		if err := client.Ping(context.Background()); err != nil {
+			return nil, err
+		}
 	}
 
 	return client, nil
 }
 
-// validate checks if the ClientConfig contains valid parameters.
-// It ensures PayMe ID and key are not empty.
-// Returns an error if validation fails.
-func (c ClientConfig) validate() error {
-	if c.PaymeID == "" {
-		return ErrEmptyOrInvalidPaycomID
+// Validate checks if the ClientConfig contains valid parameters.
+// It checks PayMe ID and key, timeouts, API urls, requisite name, language and description template,
+// and reports all problems at once, so the config can be fixed in one pass.
+// Returns nil or all problems joined with errors.Join.
+func (c ClientConfig) Validate() error {
+	var errs []error
+
+	if strings.TrimSpace(c.PaymeID) == "" {
+		errs = append(errs, ErrEmptyOrInvalidPaycomID)
+	}
+	if strings.TrimSpace(c.PaymeKey) == "" {
+		errs = append(errs, ErrEmptyOrInvalidPaycomKey)
+	}
+	if c.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("timeout %v must not be negative: %w", c.Timeout, ErrInvalidParams))
 	}
-	if c.PaymeKey == "" {
-		return ErrEmptyOrInvalidPaycomKey
+	if c.MaxRetries < 0 || c.RetryBackoff < 0 {
+		errs = append(errs, fmt.Errorf("max retries and retry backoff must not be negative: %w", ErrInvalidParams))
+	}
+	urls := []struct{ name, value string }{
+		{"base url", c.BaseURL},
+		{"subscribe url", c.SubscribeURL},
+	}
+	for _, u := range urls {
+		if u.value != "" && !isValidEndpointURL(u.value) {
+			errs = append(errs, fmt.Errorf("%s %q must be an absolute http or https url: %w", u.name, u.value, ErrInvalidParams))
+		}
+	}
+	if c.RequisiteName != "" && strings.TrimSpace(c.RequisiteName) != c.RequisiteName {
+		errs = append(errs, fmt.Errorf("requisite name %q must not have surrounding spaces: %w", c.RequisiteName, ErrInvalidParams))
+	}
+	if c.Language != "" && !IsValidLanguage(c.Language) {
+		errs = append(errs, fmt.Errorf("unsupported language %q: %w", c.Language, ErrInvalidParams))
+	}
+	if c.DescriptionTemplate != "" && !isValidDescriptionTemplate(c.DescriptionTemplate) {
+		errs = append(errs, fmt.Errorf("description template %q must contain exactly one %%s verb: %w", c.DescriptionTemplate, ErrInvalidParams))
 	}
 
-	return nil
+	return errors.Join(errs...)
+}
+
+// isValidEndpointURL checks if the url is an absolute http or https url with a host.
+func isValidEndpointURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// isValidDescriptionTemplate checks if the template has exactly one %s verb and no other verbs.
+// Escaped percent signs (%%) are allowed.
+func isValidDescriptionTemplate(template string) bool {
+	template = strings.ReplaceAll(template, "%%", "")
+	return strings.Count(template, "%") == 1 && strings.Count(template, "%s") == 1
 }
 
 // getXAuthHeaders creates authentication headers from PayMe ID and key.
@@ -128,41 +416,270 @@ func getXAuthHeaders(paymeID, paymeKey string) xAuthHeaders {
 }
 
 // sendRequest sends HTTP requests to PayMe API.
-// It handles request creation, authentication headers, timeout, and response parsing.
+// It handles request creation, authentication headers, timeout, retries, and response parsing.
 // Returns a Response struct and any error that occurred.
 func (c *Client) sendRequest(
 	ctx context.Context,
 	requestID, method string,
 	params interface{},
 	withID bool,
-	timeout ...time.Duration,
-) (*Response, error) {
-	var requestTimeout time.Duration
-
-	if len(timeout) > 0 {
-		requestTimeout = timeout[0]
-	} else {
-		requestTimeout = c.Timeout
+	opts ...RequestOption,
+) (resp *Response, err error) {
+	if c.isClosed() {
+		return nil, ErrClientClosed
 	}
 
-	// Create a context with the specified timeout.
-	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
-	defer cancel()
+	requestTimeout := c.Timeout
+	if o := applyOptions(opts); o.timeout > 0 {
+		requestTimeout = o.timeout
+	}
 
 	data := map[string]interface{}{
 		"id":     requestID,
 		"method": method,
 		"params": params,
 	}
+	if c.JSONRPCVersion != "" {
+		data["jsonrpc"] = c.JSONRPCVersion
+	}
 
 	requestBody, err := json.Marshal(data)
 	if err != nil {
 		return nil, fmt.Errorf("json marshal error: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL, bytes.NewBuffer(requestBody))
+	ctx, span := c.startSpan(ctx, method)
+	defer span.End()
+
+	span.SetAttribute("payme.method", method)
+	span.SetAttribute("payme.request_id", requestID)
+	if receiptID := receiptIDFromParams(method, params); receiptID != "" {
+		span.SetAttribute("payme.receipt_id", receiptID)
+	}
+
+	if c.SlogLogger != nil {
+		defer func() {
+			c.logRequest(ctx, method, requestID, params, err)
+		}()
+	}
+
+	if c.Metrics != nil {
+		start := time.Now()
+		defer func() {
+			c.Metrics.ObserveCall(method, time.Since(start), err)
+		}()
+	}
+
+	if c.DryRun {
+		return c.dryRunResponse(method, requestID, requestBody), nil
+	}
+
+	// receipts.pay is never retried to avoid double charges
+	maxRetries := c.MaxRetries
+	if !isRetryableMethod(method) {
+		maxRetries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		if c.RateLimiter != nil {
+			if err := c.RateLimiter.Wait(ctx); err != nil {
+				return nil, fmt.Errorf("rate limiter wait error: %w", err)
+			}
+		}
+
+		c.setLastRequestID(requestID)
+
+		resp, retryable, err := c.doRequest(ctx, span, method, requestBody, withID, requestTimeout)
+
+		// PayMe echoes the request id, mismatch means the response belongs to another request
+		if resp != nil && resp.ID != "" && resp.ID != requestID {
+			c.logf("PayMe response id mismatch - method %s request-id - %s response-id - %s", method, requestID, resp.ID)
+
+			if !c.AllowResponseIDMismatch {
+				err = fmt.Errorf("%w (request-id - %s response-id - %s)", ErrResponseIDMismatch, requestID, resp.ID)
+				span.RecordError(err)
+				return nil, err
+			}
+		}
+		if err == nil || !retryable || attempt >= maxRetries {
+			if err != nil {
+				err = rateLimitedError(err)
+				span.RecordError(err)
+			}
+			return resp, err
+		}
+
+		// Retry-After takes precedence over backoff, but never waits past the context deadline
+		delay := c.retryDelay(attempt)
+		if retryAfter := retryAfterOf(err); retryAfter > 0 {
+			delay = retryAfter
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+				err = rateLimitedError(err)
+				span.RecordError(err)
+				return resp, err
+			}
+		}
+
+		c.logf("PayMe request retry - method %s request-id - %s attempt - %d delay - %v error - %v", method, requestID, attempt+1, delay, err)
+
+		select {
+		case <-ctx.Done():
+			err = rateLimitedError(err)
+			span.RecordError(err)
+			return resp, err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// parseRetryAfter parses the Retry-After header given in seconds or as HTTP date.
+// Returns zero if the header is empty, invalid or in the past.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+
+	if date, err := http.ParseTime(header); err == nil {
+		return max(date.Sub(now), 0)
+	}
+
+	return 0
+}
+
+// dryRunResponse logs the request body and returns a synthetic success response.
+// Returns a Response with the request id.
+func (c *Client) dryRunResponse(method, requestID string, requestBody []byte) *Response {
+	c.logf("PayMe dry run - method %s request-id - %s body - %s", method, requestID, requestBody)
+
+	c.setLastRequestID(requestID)
+
+	return &Response{Jsonrpc: "2.0", ID: requestID, Result: c.dryRunResult(method)}
+}
+
+// dryRunResult returns the synthetic result of the method from DryRunResults.
+// Returns an empty object if the result is not set.
+func (c *Client) dryRunResult(method string) interface{} {
+	if result, ok := c.DryRunResults[method]; ok {
+		return result
+	}
+	return map[string]interface{}{}
+}
+
+// Close closes idle connections of the HTTP client and invalidates the client.
+// Calling any API method after Close returns ErrClientClosed.
+// Returns nil, it implements io.Closer.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+
+	c.HTTPClient.CloseIdleConnections()
+
+	return nil
+}
+
+// isClosed checks if Close was called.
+func (c *Client) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.closed
+}
+
+// LastRequestID returns the id of the last request sent by the client.
+// It can be given to PayMe support to find the request in their logs.
+// With concurrent calls it is the id of any of the latest requests.
+func (c *Client) LastRequestID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lastRequestID
+}
+
+// setLastRequestID stores the id of the request being sent.
+func (c *Client) setLastRequestID(requestID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lastRequestID = requestID
+}
+
+// doRequest sends a single HTTP request attempt to PayMe API.
+// It applies the timeout to the attempt and parses the response.
+// Request and response hooks are called for every attempt, including failed ones.
+// Returns a Response struct, whether the failure is retryable, and any error that occurred.
+func (c *Client) doRequest(ctx context.Context, span Span, method string, requestBody []byte, withID bool, timeout time.Duration) (resp *Response, retryable bool, err error) {
+	retryable, err = c.send(ctx, span, method, requestBody, withID, timeout, func(response *http.Response, responseBody []byte) error {
+		// Parse response
+		var responseJson Response
+		if err := json.Unmarshal(responseBody, &responseJson); err != nil {
+			return &HTTPStatusError{
+				StatusCode: response.StatusCode,
+				Err:        fmt.Errorf("json unmarshal error: %w, body - %s", err, TruncateString(string(responseBody), maxBodySnippet)),
+			}
+		}
+
+		// Non-200 status without JSON-RPC error is a transport failure
+		if response.StatusCode != http.StatusOK && responseJson.Error == nil {
+			return &HTTPStatusError{StatusCode: response.StatusCode}
+		}
+
+		// Handle error response with payme specific error codes
+		responseJson, err := c.handleErrorResponse(responseJson)
+		resp = &responseJson
+		if err != nil {
+			c.logf("PayMe error response - %v, error - %v", responseJson.Error, err)
+
+			span.SetAttribute("payme.error_code", responseJson.Error.Code)
+		}
+		return err
+	})
+
+	retryable = retryable ||
+		errors.Is(err, ErrPaycomServiceNotAvailable) ||
+		errors.Is(err, ErrProcessingCenterNotAvailable)
+
+	return resp, retryable, err
+}
+
+// send posts the JSON-RPC request body to PayMe API and passes the response to parse.
+// It is shared by single and batch requests: it applies the timeout, calls hooks, sets auth,
+// standard and signature headers, reads the body within MaxResponseBytes and rejects empty
+// and non-JSON bodies before parsing.
+// Returns whether the failure is retryable, and any error of the request or parse.
+func (c *Client) send(
+	ctx context.Context,
+	span Span,
+	method string,
+	requestBody []byte,
+	withID bool,
+	timeout time.Duration,
+	parse func(response *http.Response, responseBody []byte) error,
+) (retryable bool, err error) {
+	// Create a context with the specified timeout.
+	requestCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(requestCtx, "POST", c.endpointURL(), bytes.NewBuffer(requestBody))
 	if err != nil {
-		return nil, fmt.Errorf("request creation error: %w", err)
+		return false, fmt.Errorf("request creation error: %w", err)
+	}
+
+	// Call hooks
+	if c.RequestHook != nil {
+		c.RequestHook(method, requestBody)
+	}
+
+	var responseBody []byte
+	if c.ResponseHook != nil {
+		defer func() {
+			c.ResponseHook(method, responseBody, err)
+		}()
 	}
 
 	// Set headers
@@ -173,95 +690,256 @@ func (c *Client) sendRequest(
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	if c.Language != "" {
+		req.Header.Set("Accept-Language", c.Language)
+	}
+	c.signRequest(req, requestBody)
 
 	// Send request
 	response, err := c.HTTPClient.Do(req)
 	if err != nil {
+		// The caller context is done, so the request is not retried
+		if ctx.Err() != nil {
+			return false, fmt.Errorf("http request error: %w", err)
+		}
 		if errors.Is(err, context.DeadlineExceeded) {
-			return nil, ErrTimeout
+			return true, fmt.Errorf("%w: %w", ErrTimeout, err)
 		}
-		return nil, fmt.Errorf("http request error: %w", err)
+		return IsRetryable(err), fmt.Errorf("http request error: %w", err)
 	}
 	defer response.Body.Close()
 
+	span.SetAttribute("http.status_code", response.StatusCode)
+
+	// Server errors and throttled requests are retryable
+	retryable = response.StatusCode >= http.StatusInternalServerError || response.StatusCode == http.StatusTooManyRequests
+
+	// Delay requested by the server for the next attempt
+	defer func() {
+		var statusErr *HTTPStatusError
+		if errors.As(err, &statusErr) {
+			statusErr.RetryAfter = parseRetryAfter(response.Header.Get("Retry-After"), time.Now())
+		}
+	}()
+
 	// Read response body
-	responseBody, err := io.ReadAll(response.Body)
+	responseBody, err = c.readResponseBody(response)
 	if err != nil {
-		return nil, fmt.Errorf("response body read error: %w", err)
+		return retryable, err
 	}
 
-	// Parse response
-	var responseJson Response
-	err = json.Unmarshal(responseBody, &responseJson)
+	// Check response body
+	if err = checkResponseBody(response, responseBody); err != nil {
+		return retryable, err
+	}
+
+	return retryable, parse(response, responseBody)
+}
+
+// defaultUserAgent returns User-Agent in format payme-go/<version>.
+// If withMerchant is true, the first 6 characters of the merchant id are added like payme-go/1.0.0 (merchant 5e730e).
+func defaultUserAgent(paymeID string, withMerchant bool) string {
+	userAgent := "payme-go/" + Version
+	if withMerchant && paymeID != "" {
+		if len(paymeID) > 6 {
+			paymeID = paymeID[:6]
+		}
+		userAgent += fmt.Sprintf(" (merchant %s)", paymeID)
+	}
+	return userAgent
+}
+
+// signRequest sets the headers returned by SignRequest hook, replacing the standard ones.
+func (c *Client) signRequest(req *http.Request, body []byte) {
+	if c.SignRequest == nil {
+		return
+	}
+	for key, values := range c.SignRequest(body) {
+		req.Header.Del(key)
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+}
+
+// defaultMaxResponseBytes is the default max response body size.
+const defaultMaxResponseBytes = 4 << 20
+
+// readResponseBody reads the response body up to MaxResponseBytes.
+// gzip and deflate bodies are decompressed, e.g. if a custom transport disables transparent decompression,
+// and the limit is applied to the decompressed body as well.
+// Returns the body, or ErrResponseTooLarge if the body exceeds the limit.
+func (c *Client) readResponseBody(response *http.Response) ([]byte, error) {
+	maxBytes := c.MaxResponseBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxResponseBytes
+	}
+
+	body, err := readLimited(response, response.Body, maxBytes)
 	if err != nil {
-		return nil, fmt.Errorf("json unmarshal error: %w", err)
+		return nil, err
 	}
 
-	// Handle error response with payme specific error codes
-	responseJson, err = c.handleErrorResponse(responseJson)
+	var decompressor io.ReadCloser
+	switch strings.ToLower(strings.TrimSpace(response.Header.Get("Content-Encoding"))) {
+	case "gzip":
+		decompressor, err = gzip.NewReader(bytes.NewReader(body))
+	case "deflate":
+		// deflate is zlib wrapped by spec, but some servers send raw deflate
+		decompressor, err = zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			decompressor, err = flate.NewReader(bytes.NewReader(body)), nil
+		}
+	default:
+		return body, nil
+	}
 	if err != nil {
-		if c.Logger != nil {
-			c.Logger.Printf("PayMe error response - %v, error - %v", responseJson.Error, err)
+		return nil, fmt.Errorf("response body decompress error: %w", err)
+	}
+	defer decompressor.Close()
+
+	return readLimited(response, decompressor, maxBytes)
+}
+
+// readLimited reads at most maxBytes from the reader.
+// Returns the read bytes, or ErrResponseTooLarge if there are more bytes.
+func readLimited(response *http.Response, reader io.Reader, maxBytes int64) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(reader, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("response body read error: %w", err)
+	}
+	if int64(len(body)) > maxBytes {
+		return nil, &HTTPStatusError{
+			StatusCode: response.StatusCode,
+			Err:        fmt.Errorf("%w (limit - %d bytes)", ErrResponseTooLarge, maxBytes),
 		}
 	}
 
-	return &responseJson, err
+	return body, nil
+}
+
+// maxBodySnippet is the max length of the response body included in errors.
+const maxBodySnippet = 200
+
+// checkResponseBody checks the response body is not empty and has JSON content type,
+// e.g. to detect an HTML gateway timeout page of a proxy.
+// Returns HTTPStatusError wrapping ErrEmptyResponse or ErrNonJSONResponse with a truncated body.
+func checkResponseBody(response *http.Response, body []byte) error {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return &HTTPStatusError{StatusCode: response.StatusCode, Err: ErrEmptyResponse}
+	}
+
+	contentType := response.Header.Get("Content-Type")
+	if contentType != "" && !strings.Contains(contentType, "json") {
+		return &HTTPStatusError{
+			StatusCode: response.StatusCode,
+			Err:        fmt.Errorf("%w (content type - %s body - %s)", ErrNonJSONResponse, contentType, TruncateString(string(body), maxBodySnippet)),
+		}
+	}
+
+	return nil
+}
+
+// warnEnvironmentMismatch logs a warning if the client url points to the other environment.
+// e.g. a production client with the test endpoint, or a test client with the production endpoint,
+// which usually means the key of one environment is used with the other one.
+func (c *Client) warnEnvironmentMismatch() {
+	if c.SubscribeURL == EndpointFor(!c.IsTestMode) {
+		c.logf("payme warning: test mode is %t, but %s is the endpoint of other environment", c.IsTestMode, c.SubscribeURL)
+	}
+}
+
+// endpointURL returns the API url of all methods.
+// Returns BaseURL if SubscribeURL is empty, e.g. for clients created without NewClient.
+func (c *Client) endpointURL() string {
+	if c.SubscribeURL == "" {
+		return c.BaseURL
+	}
+	return c.SubscribeURL
+}
+
+// retryDelay calculates the delay before the next retry attempt.
+// It uses exponential backoff based on RetryBackoff with random jitter.
+// Returns the delay duration.
+func (c *Client) retryDelay(attempt int) time.Duration {
+	if attempt > 10 {
+		attempt = 10
+	}
+
+	backoff := c.RetryBackoff << attempt
+	if backoff <= 0 {
+		return 0
+	}
+
+	jitter := time.Duration(rand.Int63n(int64(backoff)/2 + 1))
+
+	return backoff + jitter
+}
+
+// isRetryableMethod checks if the PayMe method is safe to retry.
+// receipts.pay is not retried because it may charge the card twice.
+func isRetryableMethod(method string) bool {
+	return method != "receipts.pay"
 }
 
 // handleErrorResponse processes PayMe API error responses.
-// It maps PayMe error codes to custom error types.
-// Returns the original response and a custom error if applicable.
+// It maps PayMe error codes to custom error types and wraps them into PaymeError.
+// Returns the original response and a PaymeError if applicable.
 func (c *Client) handleErrorResponse(responseJson Response) (Response, error) {
-	var paymeError error
-
 	if responseJson.Error == nil {
 		return responseJson, nil
 	}
 
-	errorCode := responseJson.Error.Code
-
-	switch errorCode {
-	case InvalidAmountErrorCode:
-		paymeError = ErrInvalidAmount
-	case InvalidParamsErrorCode:
-		paymeError = ErrInvalidParams
-	case CardNotFoundErrorCode:
-		paymeError = ErrCardNotFound
-	case InvalidFormatTokenErrorCode:
-		paymeError = ErrInvalidFormatToken
-	case CardNumberNotFoundCode:
-		paymeError = ErrCardNotFound
-	case CardExpiredCode:
-		paymeError = ErrCardExpired
-	case ProcessingCenterNotAvailableCode:
-		paymeError = ErrProcessingCenterNotAvailable
-	case PaycomServiceNotAvailableCode:
-		paymeError = ErrPaycomServiceNotAvailable
-	case ReceiptNotFoundErrorCode:
-		paymeError = ErrReceiptNotFound
-	case ReceiptAlreadyPaidErrorCode:
-		paymeError = ErrReceiptAlreadyPaid
-	case ReceiptExpiredErrorCode:
-		paymeError = ErrReceiptExpired
-	default:
-		if errorCode != 0 {
-			paymeError = ErrPaymeError
-		}
+	paymeError := ErrorToSentinel(responseJson.Error)
+	if paymeError == nil {
+		return responseJson, nil
 	}
 
-	return responseJson, paymeError
+	return responseJson, &PaymeError{
+		Code:    responseJson.Error.Code,
+		Message: responseJson.Error.Message,
+		Data:    responseJson.Error.Data,
+		Origin:  responseJson.Error.Origin,
+		Err:     paymeError,
+	}
 }
 
 // CreateReceipt creates a new payment receipt in PayMe system.
 // It validates the amount and sends a request to receipts.create method.
 // Returns CreateReceiptResponse with receipt details or an error.
-func (c *Client) CreateReceipt(ctx context.Context, amount int64, account map[string]interface{}, description string, detail map[string]interface{}) (*CreateReceiptResponse, error) {
+func (c *Client) CreateReceipt(ctx context.Context, amount int64, account map[string]interface{}, description string, detail map[string]interface{}, opts ...RequestOption) (*CreateReceiptResponse, error) {
+	return c.createReceipt(ctx, amount, account, description, detail, 0, 0, opts...)
+}
+
+// CreateReceiptWithDetail creates a new fiscalized payment receipt with typed detail.
+// It is the same as CreateReceipt, but items are passed as ReceiptItem structs.
+// Returns CreateReceiptResponse with receipt details or an error.
+func (c *Client) CreateReceiptWithDetail(ctx context.Context, amount int64, account map[string]interface{}, description string, detail ReceiptDetailInput, opts ...RequestOption) (*CreateReceiptResponse, error) {
+	return c.createReceipt(ctx, amount, account, description, detail, 0, 0, opts...)
+}
+
+// createReceipt sends a request to receipts.create method with any detail value.
+// Currency is sent only if it is not zero, otherwise PayMe uses UZS.
+// Expire time in milliseconds is sent only if it is not zero, otherwise the receipt uses PayMe default lifetime.
+// Returns CreateReceiptResponse with receipt details or an error.
+func (c *Client) createReceipt(ctx context.Context, amount int64, account map[string]interface{}, description string, detail interface{}, currency int, expireTime int64, opts ...RequestOption) (*CreateReceiptResponse, error) {
+	account = c.mergeAccount(account)
+
 	// Validation
-	if err := ValidateAmount(amount); err != nil {
+	if err := c.validateAmount(amount); err != nil {
+		return nil, err
+	}
+	if err := c.validateAccount(account); err != nil {
 		return nil, err
 	}
+	if currency != 0 && !IsValidCurrency(currency) {
+		return nil, fmt.Errorf("unsupported currency %d: %w", currency, ErrInvalidParams)
+	}
 
-	requestID := GenerateRequestID("ReceiptsCreate")
+	requestID := c.newRequestID("ReceiptsCreate")
 
 	receiptParams := map[string]interface{}{
 		"amount":      amount,
@@ -270,7 +948,14 @@ func (c *Client) CreateReceipt(ctx context.Context, amount int64, account map[st
 		"detail":      detail,
 	}
 
-	resp, err := c.sendRequest(ctx, requestID, "receipts.create", receiptParams, false)
+	if currency != 0 {
+		receiptParams["currency"] = currency
+	}
+	if expireTime != 0 {
+		receiptParams["expire_time"] = expireTime
+	}
+
+	resp, err := c.sendRequest(ctx, requestID, "receipts.create", receiptParams, false, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -287,26 +972,178 @@ func (c *Client) CreateReceipt(ctx context.Context, amount int64, account map[st
 	return &result, nil
 }
 
+// CreateReceiptWithMoney creates a new payment receipt with the amount given as Money.
+// It is the same as CreateReceipt, but amounts are never passed through float math.
+// Returns CreateReceiptResponse with receipt details or an error.
+func (c *Client) CreateReceiptWithMoney(ctx context.Context, amount Money, account map[string]interface{}, description string, detail map[string]interface{}, opts ...RequestOption) (*CreateReceiptResponse, error) {
+	return c.CreateReceipt(ctx, amount.Tiyin(), account, description, detail, opts...)
+}
+
+// CreateReceiptWithAccount creates a new payment receipt with a typed account.
+// The account ID is sent under RequisiteName key, CardID and Reason are sent only if set.
+// Returns CreateReceiptResponse with receipt details or an error.
+func (c *Client) CreateReceiptWithAccount(ctx context.Context, amount int64, account Account, description string, opts ...RequestOption) (*CreateReceiptResponse, error) {
+	return c.CreateReceipt(ctx, amount, c.accountParams(account), description, nil, opts...)
+}
+
+// CreateSplitReceipt creates a receipt split between several account requisites, e.g. seller and platform.
+// All split requisites are sent in the account, and the sub-amounts are sent in detail.split.
+// Sub-amounts must be positive and sum to the amount, and keys must be unique.
+// Returns CreateReceiptResponse with receipt details, or ErrInvalidParams if the split doesn't reconcile.
+func (c *Client) CreateSplitReceipt(ctx context.Context, amount int64, accounts []AccountSplit, description string, opts ...RequestOption) (*CreateReceiptResponse, error) {
+	// Validation
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("split accounts are empty: %w", ErrInvalidParams)
+	}
+
+	account := make(map[string]interface{}, len(accounts))
+	split := make([]map[string]interface{}, 0, len(accounts))
+	var total int64
+	for _, part := range accounts {
+		if part.Key == "" || part.Amount <= 0 {
+			return nil, fmt.Errorf("split account must have key and positive amount: %w", ErrInvalidParams)
+		}
+		if _, ok := account[part.Key]; ok {
+			return nil, fmt.Errorf("duplicate split account key %q: %w", part.Key, ErrInvalidParams)
+		}
+
+		account[part.Key] = part.Value
+		split = append(split, map[string]interface{}{
+			"account": map[string]interface{}{part.Key: part.Value},
+			"amount":  part.Amount,
+		})
+		total += part.Amount
+	}
+	if total != amount {
+		return nil, fmt.Errorf("split amounts total %d differs from amount %d: %w", total, amount, ErrInvalidParams)
+	}
+
+	detail := map[string]interface{}{
+		"split": split,
+	}
+
+	return c.createReceipt(ctx, amount, account, description, detail, 0, 0, opts...)
+}
+
+// accountParams converts a typed account to receipt account params.
+// Returns account map with RequisiteName, card_id and reason keys.
+func (c *Client) accountParams(account Account) map[string]interface{} {
+	params := map[string]interface{}{
+		c.RequisiteName: account.ID,
+	}
+	if account.CardID != "" {
+		params["card_id"] = account.CardID
+	}
+	if account.Reason != "" {
+		params["reason"] = account.Reason
+	}
+	return params
+}
+
+// mergeAccount merges DefaultAccount fields into the receipt account.
+// Fields of the account take precedence over default fields, the given map is not modified.
+// Returns the merged account.
+func (c *Client) mergeAccount(account map[string]interface{}) map[string]interface{} {
+	if len(c.DefaultAccount) == 0 {
+		return account
+	}
+
+	merged := make(map[string]interface{}, len(c.DefaultAccount)+len(account))
+	for key, value := range c.DefaultAccount {
+		merged[key] = value
+	}
+	for key, value := range account {
+		merged[key] = value
+	}
+	return merged
+}
+
+// validateAmount validates the amount with ValidateAmount unless SkipValidation is set.
+func (c *Client) validateAmount(amount int64) error {
+	if c.SkipValidation {
+		return nil
+	}
+	return ValidateAmount(amount)
+}
+
+// validateReceiptID validates the receipt ID with ValidateReceiptID unless SkipValidation is set.
+func (c *Client) validateReceiptID(id string) error {
+	if c.SkipValidation {
+		return nil
+	}
+	return ValidateReceiptID(id)
+}
+
+// validateCardToken validates the card token with ValidateCardToken unless SkipValidation is set.
+func (c *Client) validateCardToken(token string) error {
+	if c.SkipValidation {
+		return nil
+	}
+	return ValidateCardToken(token)
+}
+
+// validateCardNumber validates the card number with ValidateCardNumber unless SkipValidation is set.
+func (c *Client) validateCardNumber(number string) error {
+	if c.SkipValidation {
+		return nil
+	}
+	return ValidateCardNumber(number)
+}
+
+// validateCardExpiry validates the card expire date at the client clock time unless SkipValidation is set.
+// Returns an error wrapping ErrInvalidParams for invalid format or ErrCardExpired for past dates.
+func (c *Client) validateCardExpiry(expire string) error {
+	if c.SkipValidation {
+		return nil
+	}
+	return validateCardExpiryAt(expire, c.now())
+}
+
+// validateVerifyCode checks the SMS verification code unless SkipValidation is set.
+// Returns ErrInvalidParams wrapped with the expected format.
+func (c *Client) validateVerifyCode(code string) error {
+	if c.SkipValidation || isValidVerifyCode(code) {
+		return nil
+	}
+	return fmt.Errorf("verify code must have 4 to 6 digits: %w", ErrInvalidParams)
+}
+
+// validateAccount checks if the account contains a non-empty value for RequisiteName.
+// It is skipped if SkipAccountValidation or SkipValidation is set.
+// Returns ErrInvalidParams wrapped with the missing requisite name.
+func (c *Client) validateAccount(account map[string]interface{}) error {
+	if c.SkipAccountValidation || c.SkipValidation {
+		return nil
+	}
+
+	value, ok := account[c.RequisiteName]
+	if !ok || value == nil || fmt.Sprint(value) == "" {
+		return fmt.Errorf("account must contain non-empty %q requisite: %w", c.RequisiteName, ErrInvalidParams)
+	}
+
+	return nil
+}
+
 // PayReceipt processes payment for an existing receipt.
 // It validates receipt ID and card token, then sends a request to receipts.pay method.
 // Returns PayReceiptResponse with payment details or an error.
-func (c *Client) PayReceipt(ctx context.Context, receiptID, token string) (*PayReceiptResponse, error) {
+func (c *Client) PayReceipt(ctx context.Context, receiptID, token string, opts ...RequestOption) (*PayReceiptResponse, error) {
 	// Validation
-	if err := ValidateReceiptID(receiptID); err != nil {
+	if err := c.validateReceiptID(receiptID); err != nil {
 		return nil, err
 	}
-	if err := ValidateCardToken(token); err != nil {
+	if err := c.validateCardToken(token); err != nil {
 		return nil, err
 	}
 
-	requestID := GenerateRequestID("ReceiptsPay")
+	requestID := c.newRequestID("ReceiptsPay")
 
 	receiptParams := map[string]interface{}{
 		"id":    receiptID,
 		"token": token,
 	}
 
-	resp, err := c.sendRequest(ctx, requestID, "receipts.pay", receiptParams, false)
+	resp, err := c.sendRequest(ctx, requestID, "receipts.pay", receiptParams, false, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -323,22 +1160,142 @@ func (c *Client) PayReceipt(ctx context.Context, receiptID, token string) (*PayR
 	return &result, nil
 }
 
+// PayReceiptWithKey processes payment for an existing receipt at most once per idempotency key.
+// The request ID is derived from the key, and duplicate calls return the cached response
+// instead of sending the payment again. If the previous call with the same key failed
+// without a PayMe response (e.g. timeout), the key stays in-flight until its ttl expires
+// and ErrIdempotencyKeyInFlight is returned, because the card may have been charged.
+// Returns PayReceiptResponse with payment details or an error.
+func (c *Client) PayReceiptWithKey(ctx context.Context, receiptID, token, idempotencyKey string, opts ...RequestOption) (*PayReceiptResponse, error) {
+	// Validation
+	if err := c.validateReceiptID(receiptID); err != nil {
+		return nil, err
+	}
+	if err := c.validateCardToken(token); err != nil {
+		return nil, err
+	}
+	if idempotencyKey == "" {
+		return nil, ErrInvalidParams
+	}
+
+	cached, err := c.IdempotencyCache.Begin(ctx, idempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil {
+		return cached, nil
+	}
+
+	requestID := fmt.Sprintf("ReceiptsPay:%s", idempotencyKey)
+
+	receiptParams := map[string]interface{}{
+		"id":    receiptID,
+		"token": token,
+	}
+
+	resp, err := c.sendRequest(ctx, requestID, "receipts.pay", receiptParams, false, opts...)
+	if err != nil {
+		// PayMe rejected the payment, so it is safe to retry with the same key
+		if resp != nil {
+			_ = c.IdempotencyCache.Abort(ctx, idempotencyKey)
+		}
+		return nil, err
+	}
+
+	// Parse result
+	var result PayReceiptResponse
+	if resp.Result != nil {
+		resultBytes, _ := json.Marshal(resp.Result)
+		if err := json.Unmarshal(resultBytes, &result); err != nil {
+			return nil, fmt.Errorf("result unmarshal error: %w", err)
+		}
+	}
+
+	if err := c.IdempotencyCache.Complete(ctx, idempotencyKey, &result); err != nil {
+		c.logf("idempotency key complete error - %v", err)
+	}
+
+	return &result, nil
+}
+
+// PayReceiptSafe processes payment for an existing receipt and safely recovers from retryable failures.
+// On a timeout or an unavailable service it checks the receipt state with CheckReceipt first,
+// returns success if the receipt is already paid, and pays again only if it is not paid, up to MaxRetries times.
+// Returns PayReceiptResponse with payment details or an error.
+func (c *Client) PayReceiptSafe(ctx context.Context, receiptID, token string, opts ...RequestOption) (*PayReceiptResponse, error) {
+	maxRetries := c.MaxRetries
+	if maxRetries < 1 {
+		maxRetries = 1
+	}
+
+	resp, err := c.PayReceipt(ctx, receiptID, token, opts...)
+	for attempt := 0; err != nil && IsRetryable(err) && attempt < maxRetries; attempt++ {
+		payErr := err
+
+		checkResp, checkErr := c.CheckReceipt(ctx, receiptID, opts...)
+		if checkErr != nil {
+			return nil, fmt.Errorf("check receipt after pay error %v: %w", payErr, checkErr)
+		}
+		if checkResp.Receipt != nil && ReceiptState(checkResp.Receipt.State) == StatePaid {
+			c.logf("Receipt %s is already paid after pay error - %v", receiptID, payErr)
+			return &PayReceiptResponse{Receipt: checkResp.Receipt}, nil
+		}
+
+		c.logf("Receipt %s is not paid after pay error - %v, retry - %d", receiptID, payErr, attempt+1)
+
+		select {
+		case <-ctx.Done():
+			return nil, payErr
+		case <-time.After(c.retryDelay(attempt)):
+		}
+
+		resp, err = c.PayReceipt(ctx, receiptID, token, opts...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
 // SendReceipt sends a receipt to the customer.
 // It validates receipt ID and sends a request to receipts.send method.
 // Returns SendReceiptResponse with send details or an error.
-func (c *Client) SendReceipt(ctx context.Context, receiptID string) (*SendReceiptResponse, error) {
+func (c *Client) SendReceipt(ctx context.Context, receiptID string, opts ...RequestOption) (*SendReceiptResponse, error) {
+	return c.sendReceipt(ctx, receiptID, "", opts...)
+}
+
+// SendReceiptToPhone sends the receipt payment link via SMS to the phone number.
+// The phone must be an Uzbek number in 998XXXXXXXXX format.
+// Returns SendReceiptResponse with send details or an error.
+func (c *Client) SendReceiptToPhone(ctx context.Context, receiptID, phone string, opts ...RequestOption) (*SendReceiptResponse, error) {
+	// Validation
+	if err := ValidateUzPhone(phone); err != nil {
+		return nil, err
+	}
+
+	return c.sendReceipt(ctx, receiptID, strings.TrimPrefix(phone, "+"), opts...)
+}
+
+// sendReceipt sends a request to receipts.send method.
+// The phone is sent only if it is not empty.
+// Returns SendReceiptResponse with send details or an error.
+func (c *Client) sendReceipt(ctx context.Context, receiptID, phone string, opts ...RequestOption) (*SendReceiptResponse, error) {
 	// Validation
-	if err := ValidateReceiptID(receiptID); err != nil {
+	if err := c.validateReceiptID(receiptID); err != nil {
 		return nil, err
 	}
 
-	requestID := GenerateRequestID("ReceiptsSend")
+	requestID := c.newRequestID("ReceiptsSend")
 
 	receiptParams := map[string]interface{}{
 		"id": receiptID,
 	}
+	if phone != "" {
+		receiptParams["phone"] = phone
+	}
 
-	resp, err := c.sendRequest(ctx, requestID, "receipts.send", receiptParams, false)
+	resp, err := c.sendRequest(ctx, requestID, "receipts.send", receiptParams, false, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -358,19 +1315,41 @@ func (c *Client) SendReceipt(ctx context.Context, receiptID string) (*SendReceip
 // CancelReceipt cancels an existing receipt.
 // It validates receipt ID and sends a request to receipts.cancel method.
 // Returns CancelReceiptResponse with cancellation details or an error.
-func (c *Client) CancelReceipt(ctx context.Context, receiptID string) (*CancelReceiptResponse, error) {
+func (c *Client) CancelReceipt(ctx context.Context, receiptID string, opts ...RequestOption) (*CancelReceiptResponse, error) {
+	return c.cancelReceipt(ctx, receiptID, 0, opts...)
+}
+
+// CancelReceiptWithReason cancels an existing receipt with a cancel reason like CancelReasonRefund.
+// The reason is shown in PayMe dashboard for refund reporting.
+// Returns CancelReceiptResponse with cancellation details, or ErrInvalidParams if the reason is unknown.
+func (c *Client) CancelReceiptWithReason(ctx context.Context, receiptID string, reason int, opts ...RequestOption) (*CancelReceiptResponse, error) {
+	// Validation
+	if !IsValidCancelReason(reason) {
+		return nil, fmt.Errorf("invalid cancel reason %d: %w", reason, ErrInvalidParams)
+	}
+
+	return c.cancelReceipt(ctx, receiptID, reason, opts...)
+}
+
+// cancelReceipt sends a request to receipts.cancel method.
+// The reason is sent only if it is not 0.
+// Returns CancelReceiptResponse with cancellation details or an error.
+func (c *Client) cancelReceipt(ctx context.Context, receiptID string, reason int, opts ...RequestOption) (*CancelReceiptResponse, error) {
 	// Validation
-	if err := ValidateReceiptID(receiptID); err != nil {
+	if err := c.validateReceiptID(receiptID); err != nil {
 		return nil, err
 	}
 
-	requestID := GenerateRequestID("ReceiptsCancel")
+	requestID := c.newRequestID("ReceiptsCancel")
 
 	receiptParams := map[string]interface{}{
 		"id": receiptID,
 	}
+	if reason != 0 {
+		receiptParams["reason"] = reason
+	}
 
-	resp, err := c.sendRequest(ctx, requestID, "receipts.cancel", receiptParams, false)
+	resp, err := c.sendRequest(ctx, requestID, "receipts.cancel", receiptParams, false, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -390,19 +1369,19 @@ func (c *Client) CancelReceipt(ctx context.Context, receiptID string) (*CancelRe
 // CheckReceipt checks the status of an existing receipt.
 // It validates receipt ID and sends a request to receipts.check method.
 // Returns CheckReceiptResponse with receipt status or an error.
-func (c *Client) CheckReceipt(ctx context.Context, receiptID string) (*CheckReceiptResponse, error) {
+func (c *Client) CheckReceipt(ctx context.Context, receiptID string, opts ...RequestOption) (*CheckReceiptResponse, error) {
 	// Validation
-	if err := ValidateReceiptID(receiptID); err != nil {
+	if err := c.validateReceiptID(receiptID); err != nil {
 		return nil, err
 	}
 
-	requestID := GenerateRequestID("ReceiptsCheck")
+	requestID := c.newRequestID("ReceiptsCheck")
 
 	receiptParams := map[string]interface{}{
 		"id": receiptID,
 	}
 
-	resp, err := c.sendRequest(ctx, requestID, "receipts.check", receiptParams, false)
+	resp, err := c.sendRequest(ctx, requestID, "receipts.check", receiptParams, false, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -422,19 +1401,19 @@ func (c *Client) CheckReceipt(ctx context.Context, receiptID string) (*CheckRece
 // GetReceipt retrieves detailed information about an existing receipt.
 // It validates receipt ID and sends a request to receipts.get method.
 // Returns GetReceiptResponse with receipt details or an error.
-func (c *Client) GetReceipt(ctx context.Context, receiptID string) (*GetReceiptResponse, error) {
+func (c *Client) GetReceipt(ctx context.Context, receiptID string, opts ...RequestOption) (*GetReceiptResponse, error) {
 	// Validation
-	if err := ValidateReceiptID(receiptID); err != nil {
+	if err := c.validateReceiptID(receiptID); err != nil {
 		return nil, err
 	}
 
-	requestID := GenerateRequestID("ReceiptsGet")
+	requestID := c.newRequestID("ReceiptsGet")
 
 	receiptParams := map[string]interface{}{
 		"id": receiptID,
 	}
 
-	resp, err := c.sendRequest(ctx, requestID, "receipts.get", receiptParams, false)
+	resp, err := c.sendRequest(ctx, requestID, "receipts.get", receiptParams, false, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -454,8 +1433,8 @@ func (c *Client) GetReceipt(ctx context.Context, receiptID string) (*GetReceiptR
 // GetAllReceipts retrieves multiple receipts within a specified time range.
 // It sends a request to receipts.get_all method with time parameters.
 // Returns GetAllReceiptsResponse with receipt list or an error.
-func (c *Client) GetAllReceipts(ctx context.Context, from, to int64, count int) (*GetAllReceiptsResponse, error) {
-	requestID := GenerateRequestID("ReceiptsGetAll")
+func (c *Client) GetAllReceipts(ctx context.Context, from, to int64, count int, opts ...RequestOption) (*GetAllReceiptsResponse, error) {
+	requestID := c.newRequestID("ReceiptsGetAll")
 
 	receiptParams := map[string]interface{}{
 		"from":  from,
@@ -463,7 +1442,7 @@ func (c *Client) GetAllReceipts(ctx context.Context, from, to int64, count int)
 		"count": count,
 	}
 
-	resp, err := c.sendRequest(ctx, requestID, "receipts.get_all", receiptParams, false)
+	resp, err := c.sendRequest(ctx, requestID, "receipts.get_all", receiptParams, false, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -471,14 +1450,11 @@ func (c *Client) GetAllReceipts(ctx context.Context, from, to int64, count int)
 	// Parse result
 	var result GetAllReceiptsResponse
 	if resp.Result != nil {
+		resultBytes, _ := json.Marshal(resp.Result)
 
-		if c.Logger != nil {
-			resultBytes, _ := json.Marshal(resp.Result)
-			c.Logger.Printf("GetAllReceipts response: %s", string(resultBytes))
-		}
+		c.logf("GetAllReceipts response: %s", string(resultBytes))
 
-		resultBytes, _ := json.Marshal(resp.Result)
-		if err := json.Unmarshal(resultBytes, &result.Receipts); err != nil {
+		if err := json.Unmarshal(resultBytes, &result); err != nil {
 			return nil, fmt.Errorf("result unmarshal error: %w", err)
 		}
 	}
@@ -489,20 +1465,38 @@ func (c *Client) GetAllReceipts(ctx context.Context, from, to int64, count int)
 // SetFiscalData sets fiscal data for an existing receipt.
 // It validates receipt ID and sends a request to receipts.set_fiscal_data method.
 // Returns SetFiscalDataResponse with fiscal data details or an error.
-func (c *Client) SetFiscalData(ctx context.Context, receiptID string, fiscalData map[string]interface{}) (*SetFiscalDataResponse, error) {
+func (c *Client) SetFiscalData(ctx context.Context, receiptID string, fiscalData map[string]interface{}, opts ...RequestOption) (*SetFiscalDataResponse, error) {
+	return c.setFiscalData(ctx, receiptID, fiscalData, opts...)
+}
+
+// SetFiscalDataTyped sets typed fiscal data received from OFD for an existing receipt.
+// It validates required fiscal data fields and sends a request to receipts.set_fiscal_data method.
+// Returns SetFiscalDataResponse with fiscal data details or an error.
+func (c *Client) SetFiscalDataTyped(ctx context.Context, receiptID string, data FiscalData, opts ...RequestOption) (*SetFiscalDataResponse, error) {
+	// Validation
+	if err := data.Validate(); err != nil {
+		return nil, err
+	}
+
+	return c.setFiscalData(ctx, receiptID, data, opts...)
+}
+
+// setFiscalData sends a request to receipts.set_fiscal_data method.
+// Returns SetFiscalDataResponse with fiscal data details or an error.
+func (c *Client) setFiscalData(ctx context.Context, receiptID string, fiscalData interface{}, opts ...RequestOption) (*SetFiscalDataResponse, error) {
 	// Validation
-	if err := ValidateReceiptID(receiptID); err != nil {
+	if err := c.validateReceiptID(receiptID); err != nil {
 		return nil, err
 	}
 
-	requestID := GenerateRequestID("ReceiptsSetFiscalData")
+	requestID := c.newRequestID("ReceiptsSetFiscalData")
 
 	receiptParams := map[string]interface{}{
 		"id":          receiptID,
 		"fiscal_data": fiscalData,
 	}
 
-	resp, err := c.sendRequest(ctx, requestID, "receipts.set_fiscal_data", receiptParams, false)
+	resp, err := c.sendRequest(ctx, requestID, "receipts.set_fiscal_data", receiptParams, false, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -523,19 +1517,75 @@ func (c *Client) SetFiscalData(ctx context.Context, receiptID string, fiscalData
 
 // CreateAndPayReceipt creates a receipt and immediately processes payment.
 // This is a convenience method that combines CreateReceipt and PayReceipt.
-// Returns PayReceiptResponse with payment details or an error.
-func (c *Client) CreateAndPayReceipt(ctx context.Context, amount int64, account map[string]interface{}, description string, token string) (*PayReceiptResponse, error) {
+// If payment fails, the result still contains the created receipt ID and PayErr,
+// and the receipt is canceled if CancelOnPayFailure is set.
+// Returns CreateAndPayResult with the paid receipt, or the result with an error if payment failed.
+func (c *Client) CreateAndPayReceipt(ctx context.Context, amount int64, account map[string]interface{}, description string, token string, opts ...RequestOption) (*CreateAndPayResult, error) {
 	// Create receipt
-	createResp, err := c.CreateReceipt(ctx, amount, account, description, nil)
+	createResp, err := c.CreateReceipt(ctx, amount, account, description, nil, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("create receipt error: %w", err)
 	}
+	if createResp.Receipt == nil {
+		return nil, fmt.Errorf("create receipt error: %w", ErrReceiptNotFound)
+	}
+
+	result := &CreateAndPayResult{ReceiptID: createResp.Receipt.ID}
 
 	// Pay receipt
-	payResp, err := c.PayReceipt(ctx, createResp.Receipt.ID, token)
+	payResp, err := c.PayReceipt(ctx, result.ReceiptID, token, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("pay receipt error: %w", err)
+		return c.payFailed(ctx, result, err)
+	}
+
+	result.Receipt = payResp.Receipt
+
+	return result, nil
+}
+
+// payFailureCancelTimeout limits the receipt cancel after a failed payment.
+const payFailureCancelTimeout = 10 * time.Second
+
+// payFailed records the payment error in the result and cancels the receipt if CancelOnPayFailure is set.
+// The cancel is sent even if the caller context is done, since the payment may have failed because of it.
+// Returns the result with the wrapped payment error.
+func (c *Client) payFailed(ctx context.Context, result *CreateAndPayResult, err error) (*CreateAndPayResult, error) {
+	result.PayErr = err
+
+	if c.CancelOnPayFailure {
+		cancelCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), payFailureCancelTimeout)
+		defer cancel()
+
+		if _, cancelErr := c.CancelReceipt(cancelCtx, result.ReceiptID); cancelErr != nil {
+			c.logf("Failed to cancel receipt %s after pay failure: %v", result.ReceiptID, cancelErr)
+		} else {
+			result.Canceled = true
+		}
+	}
+
+	return result, fmt.Errorf("pay receipt %s error: %w", result.ReceiptID, err)
+}
+
+// pingReceiptID is a well-formed receipt ID which does not exist in PayMe system.
+const pingReceiptID = "000000000000000000000000"
+
+// Ping checks that PayMe endpoint is reachable and the credentials are accepted.
+// It sends receipts.check with a non-existent receipt ID, so any PayMe error except permission denied means success.
+// Returns nil if the endpoint accepted the credentials, ErrEmptyOrInvalidPaycomKey on auth error, or a network error.
+func (c *Client) Ping(ctx context.Context, opts ...RequestOption) error {
+	_, err := c.CheckReceipt(ctx, pingReceiptID, opts...)
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, ErrPermissionDenied) {
+		return fmt.Errorf("%w: %w", ErrEmptyOrInvalidPaycomKey, err)
+	}
+
+	var paymeErr *PaymeError
+	if errors.As(err, &paymeErr) {
+		return nil
 	}
 
-	return payResp, nil
+	return fmt.Errorf("ping error: %w", err)
 }
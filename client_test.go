@@ -0,0 +1,664 @@
+package payment
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestErrorCodesMapToSentinels(t *testing.T) {
+	tests := []struct {
+		code int
+		want error
+	}{
+		{InvalidAmountErrorCode, ErrInvalidAmount},
+		{InvalidParamsErrorCode, ErrInvalidParams},
+		{CardNotFoundErrorCode, ErrCardNotFound},
+		{InvalidFormatTokenErrorCode, ErrInvalidFormatToken},
+		{CardNumberNotFoundCode, ErrCardNumberNotFound},
+		{CardExpiredCode, ErrCardExpired},
+		{P2PIdenticalCardsErrorCode, ErrP2PIdenticalCards},
+		{VerifyCodeSendFailedErrorCode, ErrVerifyCodeSendFailed},
+		{InvalidVerifyCodeErrorCode, ErrInvalidVerifyCode},
+		{PaycomServiceNotAvailableCode, ErrPaycomServiceNotAvailable},
+		{ProcessingCenterNotAvailableCode, ErrProcessingCenterNotAvailable},
+		{ReceiptNotFoundErrorCode, ErrReceiptNotFound},
+		{ReceiptAlreadyPaidErrorCode, ErrReceiptAlreadyPaid},
+		{ReceiptExpiredErrorCode, ErrReceiptExpired},
+		{PermissionDeniedCode, ErrPermissionDenied},
+		{ParseErrorCode, ErrParseError},
+		{MethodNotFoundCode, ErrMethodNotFound},
+		{InvalidRequestCode, ErrInvalidRequest},
+		{-31999, ErrPaymeError},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.code), func(t *testing.T) {
+			server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+				return nil, &Error{Code: tt.code, Message: "mocked error"}
+			})
+			client := newTestClient(t, server.URL, func(config *ClientConfig) {
+				config.MaxRetries = 0
+			})
+
+			_, err := client.GetReceipt(context.Background(), "receipt-1")
+			if !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+			if got := GetErrorCode(err); got != tt.code {
+				t.Errorf("GetErrorCode = %d, want %d", got, tt.code)
+			}
+		})
+	}
+}
+
+func TestGetAllReceiptsParsesBothResultShapes(t *testing.T) {
+	results := map[string]interface{}{
+		"bare array": []Receipt{{ID: "r1"}, {ID: "r2"}},
+		"object":     map[string]interface{}{"receipts": []Receipt{{ID: "r1"}, {ID: "r2"}}},
+	}
+
+	for name, result := range results {
+		t.Run(name, func(t *testing.T) {
+			server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+				return result, nil
+			})
+			client := newTestClient(t, server.URL)
+
+			resp, err := client.GetAllReceipts(context.Background(), 1700000000000, 1700086400000, 10)
+			if err != nil {
+				t.Fatalf("GetAllReceipts error: %v", err)
+			}
+			if len(resp.Receipts) != 2 || resp.Receipts[0].ID != "r1" || resp.Receipts[1].ID != "r2" {
+				t.Errorf("receipts = %+v, want r1 and r2", resp.Receipts)
+			}
+		})
+	}
+}
+
+func TestResponseIDMismatch(t *testing.T) {
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Response{Jsonrpc: "2.0", ID: "ReceiptsGet:other", Result: receiptResult(Receipt{ID: "receipt-1"})})
+	}))
+	t.Cleanup(server.Close)
+
+	t.Run("rejected", func(t *testing.T) {
+		client := newTestClient(t, server.URL)
+
+		_, err := client.GetReceipt(context.Background(), "receipt-1")
+		if !errors.Is(err, ErrResponseIDMismatch) {
+			t.Errorf("error = %v, want ErrResponseIDMismatch", err)
+		}
+		if !strings.Contains(fmt.Sprint(err), "response-id - ReceiptsGet:other") {
+			t.Errorf("error = %v, want response id", err)
+		}
+	})
+
+	t.Run("allowed", func(t *testing.T) {
+		client := newTestClient(t, server.URL, func(config *ClientConfig) {
+			config.AllowResponseIDMismatch = true
+		})
+
+		resp, err := client.GetReceipt(context.Background(), "receipt-1")
+		if err != nil {
+			t.Fatalf("GetReceipt error: %v", err)
+		}
+		if resp.Receipt.ID != "receipt-1" {
+			t.Errorf("receipt id = %s, want receipt-1", resp.Receipt.ID)
+		}
+	})
+
+	// Mismatch is not retried
+	if calls.Load() != 2 {
+		t.Errorf("requests = %d, want 2", calls.Load())
+	}
+}
+
+func TestHTTPStatusErrorOnTransportFailure(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+	}{
+		{"proxy html page", http.StatusServiceUnavailable, "text/html", "<html><body>503 Service Temporarily Unavailable</body></html>"},
+		{"json without error", http.StatusBadGateway, "application/json", `{"jsonrpc":"2.0"}`},
+		{"broken json", http.StatusGatewayTimeout, "", "upstream timed out"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int64
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			t.Cleanup(server.Close)
+			client := newTestClient(t, server.URL, func(config *ClientConfig) {
+				config.MaxRetries = 1
+			})
+
+			_, err := client.GetReceipt(context.Background(), "receipt-1")
+
+			var statusErr *HTTPStatusError
+			if !errors.As(err, &statusErr) {
+				t.Fatalf("error = %v, want HTTPStatusError", err)
+			}
+			if statusErr.StatusCode != tt.status {
+				t.Errorf("StatusCode = %d, want %d", statusErr.StatusCode, tt.status)
+			}
+			if !errors.Is(err, ErrHTTPStatus) {
+				t.Errorf("error = %v, want ErrHTTPStatus", err)
+			}
+			if IsPaymeError(err) {
+				t.Errorf("IsPaymeError(%v) = true, want false", err)
+			}
+			// 5xx is retried
+			if calls.Load() != 2 {
+				t.Errorf("requests = %d, want 2", calls.Load())
+			}
+		})
+	}
+}
+
+func TestEmptyAndNonJSONResponseBodies(t *testing.T) {
+	page := "<html><head><title>Maintenance</title></head><body>" + strings.Repeat("x", 500) + "</body></html>"
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        error
+		snippet     string
+	}{
+		{"empty", "application/json", "", ErrEmptyResponse, ""},
+		{"whitespace", "application/json", " \r\n ", ErrEmptyResponse, ""},
+		{"html", "text/html; charset=utf-8", page, ErrNonJSONResponse, "<html><head><title>Maintenance</title>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			t.Cleanup(server.Close)
+			client := newTestClient(t, server.URL)
+
+			_, err := client.GetReceipt(context.Background(), "receipt-1")
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("error = %v, want %v", err, tt.want)
+			}
+
+			message := err.Error()
+			if !strings.Contains(message, tt.snippet) {
+				t.Errorf("error = %s, want body snippet %q", message, tt.snippet)
+			}
+			// Only the beginning of the body is kept
+			if strings.Contains(message, strings.Repeat("x", 300)) {
+				t.Errorf("error contains the whole body: %s", message)
+			}
+		})
+	}
+}
+
+func TestPayReceiptSafe(t *testing.T) {
+	t.Run("already paid after timeout", func(t *testing.T) {
+		var pays, checks atomic.Int64
+		server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+			switch call.Method {
+			case "receipts.pay":
+				// Paid by PayMe, but the response is late
+				pays.Add(1)
+				time.Sleep(100 * time.Millisecond)
+				return receiptResult(Receipt{ID: "receipt-1", State: int(StatePaid)}), nil
+			case "receipts.check":
+				checks.Add(1)
+				return receiptResult(Receipt{ID: "receipt-1", State: int(StatePaid), PayTime: 1700000000000}), nil
+			}
+			return nil, &Error{Code: MethodNotFoundCode}
+		})
+		client := newTestClient(t, server.URL, func(config *ClientConfig) {
+			config.Timeout = 20 * time.Millisecond
+			config.MaxRetries = 1
+		})
+
+		resp, err := client.PayReceiptSafe(context.Background(), "receipt-1", "card-token-123")
+		if err != nil {
+			t.Fatalf("PayReceiptSafe error: %v", err)
+		}
+		if resp.Receipt.PayTime != 1700000000000 {
+			t.Errorf("receipt = %+v, want checked receipt", resp.Receipt)
+		}
+		if checks.Load() != 1 {
+			t.Errorf("checks = %d, want 1", checks.Load())
+		}
+		// Not paid again after the check
+		if n := pays.Load(); n != 1 {
+			t.Errorf("pays = %d, want 1", n)
+		}
+	})
+
+	t.Run("paid again when not paid", func(t *testing.T) {
+		var checked atomic.Bool
+		server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+			switch call.Method {
+			case "receipts.pay":
+				if !checked.Load() {
+					return nil, &Error{Code: ProcessingCenterNotAvailableCode, Message: "unavailable"}
+				}
+				return receiptResult(Receipt{ID: "receipt-1", State: int(StatePaid)}), nil
+			case "receipts.check":
+				checked.Store(true)
+				return receiptResult(Receipt{ID: "receipt-1", State: int(StateCreated)}), nil
+			}
+			return nil, &Error{Code: MethodNotFoundCode}
+		})
+		client := newTestClient(t, server.URL, func(config *ClientConfig) {
+			config.MaxRetries = 1
+		})
+
+		resp, err := client.PayReceiptSafe(context.Background(), "receipt-1", "card-token-123")
+		if err != nil {
+			t.Fatalf("PayReceiptSafe error: %v", err)
+		}
+		if ReceiptState(resp.Receipt.State) != StatePaid {
+			t.Errorf("state = %d, want paid", resp.Receipt.State)
+		}
+	})
+
+	t.Run("not retryable", func(t *testing.T) {
+		server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+			if call.Method == "receipts.check" {
+				t.Error("unexpected receipts.check request")
+			}
+			return nil, &Error{Code: ReceiptExpiredErrorCode, Message: "expired"}
+		})
+		client := newTestClient(t, server.URL)
+
+		_, err := client.PayReceiptSafe(context.Background(), "receipt-1", "card-token-123")
+		if !errors.Is(err, ErrReceiptExpired) {
+			t.Errorf("error = %v, want ErrReceiptExpired", err)
+		}
+	})
+}
+
+func TestRequestBodyContainsJSONRPCVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		want    string
+	}{
+		{"default", "", "2.0"},
+		{"configured", "1.0", "1.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]interface{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("request decode error: %v", err)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(Response{ID: body["id"].(string), Result: receiptResult(Receipt{ID: "receipt-1"})})
+			}))
+			t.Cleanup(server.Close)
+			client := newTestClient(t, server.URL, func(config *ClientConfig) {
+				config.JSONRPCVersion = tt.version
+			})
+
+			if _, err := client.GetReceipt(context.Background(), "receipt-1"); err != nil {
+				t.Fatalf("GetReceipt error: %v", err)
+			}
+			if body["jsonrpc"] != tt.want {
+				t.Errorf("jsonrpc = %v, want %s", body["jsonrpc"], tt.want)
+			}
+		})
+	}
+}
+
+// newRawReceiptServer starts a test server answering receipts.get with a receipt of the description.
+// The encode function can compress the response body and returns its Content-Encoding.
+func newRawReceiptServer(t *testing.T, description string, encode func(body []byte) ([]byte, string)) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var call rpcCall
+		if err := json.NewDecoder(r.Body).Decode(&call); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		body, _ := json.Marshal(Response{Jsonrpc: "2.0", ID: call.ID, Result: receiptResult(Receipt{ID: "receipt-1", Description: description})})
+		if encode != nil {
+			var encoding string
+			body, encoding = encode(body)
+			w.Header().Set("Content-Encoding", encoding)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestMaxResponseBytes(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		wantErr     bool
+	}{
+		{"under limit", strings.Repeat("a", 100), false},
+		{"over limit", strings.Repeat("a", 2000), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newRawReceiptServer(t, tt.description, nil)
+			client := newTestClient(t, server.URL, func(config *ClientConfig) {
+				config.MaxResponseBytes = 1024
+			})
+
+			resp, err := client.GetReceipt(context.Background(), "receipt-1")
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("GetReceipt error: %v", err)
+				}
+				if resp.Receipt.Description != tt.description {
+					t.Errorf("description length = %d, want %d", len(resp.Receipt.Description), len(tt.description))
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrResponseTooLarge) {
+				t.Fatalf("error = %v, want ErrResponseTooLarge", err)
+			}
+			if !strings.Contains(err.Error(), "limit - 1024 bytes") {
+				t.Errorf("error = %v, want limit", err)
+			}
+		})
+	}
+}
+
+func TestCompressedResponseBodies(t *testing.T) {
+	gzipBody := func(body []byte) ([]byte, string) {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		_, _ = w.Write(body)
+		_ = w.Close()
+		return buf.Bytes(), "gzip"
+	}
+	zlibBody := func(body []byte) ([]byte, string) {
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		_, _ = w.Write(body)
+		_ = w.Close()
+		return buf.Bytes(), "deflate"
+	}
+	rawDeflateBody := func(body []byte) ([]byte, string) {
+		var buf bytes.Buffer
+		w, _ := flate.NewWriter(&buf, flate.DefaultCompression)
+		_, _ = w.Write(body)
+		_ = w.Close()
+		return buf.Bytes(), "deflate"
+	}
+
+	tests := []struct {
+		name   string
+		encode func(body []byte) ([]byte, string)
+	}{
+		{"gzip", gzipBody},
+		{"zlib deflate", zlibBody},
+		{"raw deflate", rawDeflateBody},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newRawReceiptServer(t, "compressed receipt", tt.encode)
+			// Transparent decompression is disabled, so the client decompresses the body itself
+			client := newTestClient(t, server.URL, func(config *ClientConfig) {
+				config.HTTPClient.Transport = &http.Transport{DisableCompression: true}
+			})
+
+			resp, err := client.GetReceipt(context.Background(), "receipt-1")
+			if err != nil {
+				t.Fatalf("GetReceipt error: %v", err)
+			}
+			if resp.Receipt.Description != "compressed receipt" {
+				t.Errorf("description = %q, want compressed receipt", resp.Receipt.Description)
+			}
+		})
+	}
+
+	t.Run("decompressed body over limit", func(t *testing.T) {
+		// Small compressed body expanding beyond the limit
+		server := newRawReceiptServer(t, strings.Repeat("a", 100000), gzipBody)
+		client := newTestClient(t, server.URL, func(config *ClientConfig) {
+			config.HTTPClient.Transport = &http.Transport{DisableCompression: true}
+			config.MaxResponseBytes = 4096
+		})
+
+		_, err := client.GetReceipt(context.Background(), "receipt-1")
+		if !errors.Is(err, ErrResponseTooLarge) {
+			t.Errorf("error = %v, want ErrResponseTooLarge", err)
+		}
+	})
+
+	t.Run("corrupt gzip", func(t *testing.T) {
+		server := newRawReceiptServer(t, "", func(body []byte) ([]byte, string) {
+			return body, "gzip"
+		})
+		client := newTestClient(t, server.URL, func(config *ClientConfig) {
+			config.HTTPClient.Transport = &http.Transport{DisableCompression: true}
+		})
+
+		_, err := client.GetReceipt(context.Background(), "receipt-1")
+		if err == nil || !strings.Contains(err.Error(), "response body decompress error") {
+			t.Errorf("error = %v, want decompress error", err)
+		}
+	})
+}
+
+func TestPing(t *testing.T) {
+	tests := []struct {
+		name   string
+		rpcErr *Error
+		want   error
+	}{
+		{"receipt found", nil, nil},
+		{"receipt not found means valid credentials", &Error{Code: ReceiptNotFoundErrorCode, Message: "not found"}, nil},
+		{"permission denied", &Error{Code: PermissionDeniedCode, Message: "access denied"}, ErrEmptyOrInvalidPaycomKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+				if tt.rpcErr != nil {
+					return nil, tt.rpcErr
+				}
+				return receiptResult(Receipt{ID: pingReceiptID}), nil
+			})
+			client := newTestClient(t, server.URL)
+
+			err := client.Ping(context.Background())
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("Ping error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.want) || !errors.Is(err, ErrPermissionDenied) {
+				t.Errorf("error = %v, want %v wrapping ErrPermissionDenied", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewClientPingOnCreate(t *testing.T) {
+	server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+		return nil, &Error{Code: PermissionDeniedCode, Message: "access denied"}
+	})
+
+	_, err := NewClient(ClientConfig{
+		PaymeID:      "5e730e8e0b852a417aa49ceb",
+		PaymeKey:     "wrong-key",
+		IsTestMode:   true,
+		BaseURL:      server.URL,
+		PingOnCreate: true,
+	})
+	if !errors.Is(err, ErrEmptyOrInvalidPaycomKey) {
+		t.Errorf("error = %v, want ErrEmptyOrInvalidPaycomKey", err)
+	}
+
+	// Transport failures are reported as ping errors
+	_, err = NewClient(ClientConfig{
+		PaymeID:      "5e730e8e0b852a417aa49ceb",
+		PaymeKey:     "test-key",
+		IsTestMode:   true,
+		BaseURL:      "http://127.0.0.1:1",
+		RetryBackoff: time.Millisecond,
+		PingOnCreate: true,
+	})
+	if err == nil || !strings.Contains(err.Error(), "ping error") {
+		t.Errorf("error = %v, want ping error", err)
+	}
+}
+
+func TestUserAgentHeader(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(config *ClientConfig)
+		want      string
+	}{
+		{"default", func(config *ClientConfig) {}, "payme-go/" + Version},
+		{"with merchant", func(config *ClientConfig) { config.UserAgentWithMerchant = true }, "payme-go/" + Version + " (merchant 5e730e)"},
+		{"custom", func(config *ClientConfig) { config.UserAgent = "shop-backend/2.1" }, "shop-backend/2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var userAgent string
+			server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+				userAgent = call.Header.Get("User-Agent")
+				return receiptResult(Receipt{ID: "receipt-1"}), nil
+			})
+			client := newTestClient(t, server.URL, tt.configure)
+
+			if _, err := client.GetReceipt(context.Background(), "receipt-1"); err != nil {
+				t.Fatalf("GetReceipt error: %v", err)
+			}
+			if userAgent != tt.want {
+				t.Errorf("User-Agent = %q, want %q", userAgent, tt.want)
+			}
+		})
+	}
+}
+
+// countingLimiter is a RateLimiter counting Wait calls.
+type countingLimiter struct {
+	calls atomic.Int64
+}
+
+func (l *countingLimiter) Wait(ctx context.Context) error {
+	l.calls.Add(1)
+	return ctx.Err()
+}
+
+func TestClientConcurrentReceiptCalls(t *testing.T) {
+	const workers, callsPerWorker = 20, 10
+
+	var served, created atomic.Int64
+	server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+		served.Add(1)
+		switch call.Method {
+		case "receipts.create":
+			return receiptResult(Receipt{ID: fmt.Sprintf("%024x", created.Add(1)), State: 0}), nil
+		case "receipts.get":
+			return receiptResult(Receipt{ID: call.Params["id"].(string), State: 4}), nil
+		}
+		return nil, &Error{Code: MethodNotFoundCode, Message: "unknown method"}
+	})
+
+	limiter := &countingLimiter{}
+	var hooked atomic.Int64
+	client := newTestClient(t, server.URL, func(config *ClientConfig) {
+		config.RateLimiter = limiter
+		config.RequestHook = func(method string, body []byte) { hooked.Add(1) }
+	})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*callsPerWorker)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			ctx := context.Background()
+			for i := 0; i < callsPerWorker; i++ {
+				created, err := client.CreateReceipt(ctx, 10000, map[string]interface{}{"id": fmt.Sprintf("%d-%d", w, i)}, "order", nil)
+				if err != nil {
+					errs <- fmt.Errorf("CreateReceipt error: %w", err)
+					continue
+				}
+				got, err := client.GetReceipt(ctx, created.Receipt.ID)
+				if err != nil {
+					errs <- fmt.Errorf("GetReceipt error: %w", err)
+					continue
+				}
+				if got.Receipt.ID != created.Receipt.ID {
+					errs <- fmt.Errorf("GetReceipt id = %s, want %s", got.Receipt.ID, created.Receipt.ID)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	want := int64(2 * workers * callsPerWorker)
+	if served.Load() != want {
+		t.Errorf("served requests = %d, want %d", served.Load(), want)
+	}
+	if limiter.calls.Load() != want {
+		t.Errorf("rate limiter waits = %d, want %d", limiter.calls.Load(), want)
+	}
+	if hooked.Load() != want {
+		t.Errorf("request hook calls = %d, want %d", hooked.Load(), want)
+	}
+}
+
+func TestNewClientPrefersConfigRateLimiter(t *testing.T) {
+	limiter := &countingLimiter{}
+	client := newTestClient(t, "http://127.0.0.1", func(config *ClientConfig) {
+		config.RateLimit = RateLimit{RequestsPerSecond: 1, Burst: 1}
+		config.RateLimiter = limiter
+	})
+
+	if client.RateLimiter != limiter {
+		t.Errorf("RateLimiter = %T, want the configured limiter", client.RateLimiter)
+	}
+}
@@ -0,0 +1,42 @@
+package payment
+
+import "time"
+
+// Clock provides the current time to the client.
+// It can be replaced with paymetest.FakeClock to get deterministic request ids and time windows in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the default Clock returning time.Now.
+type systemClock struct{}
+
+// Now returns the current local time.
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// now returns the current time of the client clock.
+func (c *Client) now() time.Time {
+	if c.Clock == nil {
+		return time.Now()
+	}
+	return c.Clock.Now()
+}
+
+// newRequestID creates a request id with the prefix at the client clock time.
+// The counter belongs to the client, so a new client with a fake clock creates the same ids on every run.
+func (c *Client) newRequestID(prefix string) string {
+	return formatRequestID(prefix, c.now(), c.requestSeq.Add(1))
+}
+
+// CurrentTimestamp returns the client clock time in milliseconds.
+func (c *Client) CurrentTimestamp() int64 {
+	return c.now().UnixMilli()
+}
+
+// GenerateReceiptID creates a receipt identifier for the charge at the client clock time.
+// Returns a string in format "receipt_chargeID_seconds".
+func (c *Client) GenerateReceiptID(chargeID string) string {
+	return GenerateReceiptIDAt(chargeID, c.now())
+}
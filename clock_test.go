@@ -0,0 +1,27 @@
+package payment
+
+import (
+	"testing"
+	"time"
+)
+
+// fixedClock is a Clock always returning the same time.
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+func TestClientTimeHelpersUseClock(t *testing.T) {
+	at := time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)
+	client := newTestClient(t, "http://127.0.0.1", func(config *ClientConfig) {
+		config.Clock = fixedClock(at)
+	})
+
+	if got, want := client.CurrentTimestamp(), at.UnixMilli(); got != want {
+		t.Errorf("CurrentTimestamp() = %d, want %d", got, want)
+	}
+	if got, want := client.GenerateReceiptID("ch-1"), "receipt_ch-1_1710498600"; got != want {
+		t.Errorf("GenerateReceiptID() = %q, want %q", got, want)
+	}
+}
@@ -1,6 +1,18 @@
 package payment
 
-import "errors"
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
 
 const (
 	InvalidAmountErrorCode      = -31611
@@ -15,6 +27,9 @@ const (
 	CardExpiredCode             = -31301
 	P2PIdenticalCardsErrorCode  = -31630
 
+	VerifyCodeSendFailedErrorCode = -31101
+	InvalidVerifyCodeErrorCode    = -31103
+
 	PaycomServiceNotAvailableCode    = -31001
 	ProcessingCenterNotAvailableCode = -31002
 
@@ -22,6 +37,17 @@ const (
 	ParseErrorCode       = -32700
 	MethodNotFoundCode   = -32601
 	InvalidRequestCode   = -32600
+	SystemErrorCode      = -32400
+
+	// Merchant API error codes
+	MerchantInvalidAmountCode       = -31001
+	MerchantTransactionNotFoundCode = -31003
+	UnableToCancelErrorCode         = -31007
+	UnableToPerformErrorCode        = -31008
+
+	// account errors of Merchant API are in range -31099..-31050
+	AccountErrorCodeMin = -31099
+	AccountErrorCodeMax = -31050
 )
 
 var (
@@ -37,6 +63,9 @@ var (
 	ErrCardExpired        = errors.New("card expired")
 	ErrP2PIdenticalCards  = errors.New("similar cards cannot be used for P2P processing")
 
+	ErrVerifyCodeSendFailed = errors.New("verification code send failed")
+	ErrInvalidVerifyCode    = errors.New("invalid verification code")
+
 	ErrPaycomServiceNotAvailable    = errors.New("paycom service not available")
 	ErrProcessingCenterNotAvailable = errors.New("processing center not available")
 
@@ -45,62 +74,342 @@ var (
 	ErrMethodNotFound   = errors.New("method not found")
 	ErrInvalidRequest   = errors.New("invalid request")
 
+	ErrOrderHasTransaction = errors.New("order already has a transaction")
+	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrUnableToCancel      = errors.New("unable to cancel transaction")
+	ErrUnableToPerform     = errors.New("unable to perform operation")
+
 	ErrPaymeError              = errors.New("payme error was occurred")
 	ErrTimeout                 = errors.New("request timeout exceeded")
+	ErrIdempotencyKeyInFlight  = errors.New("idempotency key is in flight")
+	ErrReceiptCanceled         = errors.New("receipt canceled")
+	ErrReceiptNotPaid          = errors.New("receipt not paid")
+	ErrAmountMismatch          = errors.New("paid amount does not match expected amount")
+	ErrResponseIDMismatch      = errors.New("response id does not match request id")
+	ErrCertificateNotPinned    = errors.New("certificate is not pinned")
+	ErrHTTPStatus              = errors.New("unexpected http status")
+	ErrEmptyResponse           = errors.New("empty response body")
+	ErrNonJSONResponse         = errors.New("non-JSON response body")
+	ErrClientClosed            = errors.New("client is closed")
+	ErrResponseTooLarge        = errors.New("response body too large")
+	ErrRateLimited             = errors.New("rate limited by payme")
 	ErrEmptyOrInvalidPaycomID  = errors.New("invalid paycom ID")
 	ErrEmptyOrInvalidPaycomKey = errors.New("invalid paycom key")
 )
 
-func IsPaymeError(err error) bool {
-	switch err {
-	case ErrReceiptNotFound, ErrReceiptAlreadyPaid, ErrReceiptExpired,
-		ErrInvalidAmount, ErrInvalidParams, ErrCardNotFound, ErrInvalidFormatToken,
-		ErrCardNumberNotFound, ErrCardExpired, ErrP2PIdenticalCards,
-		ErrPaycomServiceNotAvailable, ErrProcessingCenterNotAvailable,
-		ErrPermissionDenied, ErrParseError, ErrMethodNotFound, ErrInvalidRequest:
-		return true
+// PaymeError represents a PayMe API error with all details of the error response.
+// It keeps the sentinel error matching the code, so errors.Is(err, ErrInvalidParams)
+// still works for errors returned by the client.
+type PaymeError struct {
+	// PayMe error code
+	Code int
+	// error message
+	Message string
+	// error data like invalid account field name
+	Data string
+	// error origin like receipts.create
+	Origin string
+	// sentinel error matching the code
+	Err error
+}
+
+// Error returns the sentinel error with PayMe error details as string.
+func (e *PaymeError) Error() string {
+	return fmt.Sprintf("%v (code - %d message - %s data - %s origin - %s)", e.Err, e.Code, e.Message, e.Data, e.Origin)
+}
+
+// Unwrap returns the sentinel error of PaymeError for errors.Is and errors.As.
+func (e *PaymeError) Unwrap() error {
+	return e.Err
+}
+
+// HTTPStatusError represents a transport level failure with the HTTP status code of the response,
+// e.g. a 503 HTML page from a proxy. errors.Is(err, ErrHTTPStatus) reports true for it.
+type HTTPStatusError struct {
+	// HTTP status code of the response
+	StatusCode int
+	// underlying error like json unmarshal error
+	Err error
+	// delay requested by Retry-After header of 429 and 503 responses, zero if not set
+	RetryAfter time.Duration
+}
+
+// Error returns the HTTP status code with the underlying error as string.
+func (e *HTTPStatusError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%v (status - %d)", ErrHTTPStatus, e.StatusCode)
+	}
+	return fmt.Sprintf("%v (status - %d): %v", ErrHTTPStatus, e.StatusCode, e.Err)
+}
+
+// Unwrap returns ErrHTTPStatus and the underlying error for errors.Is and errors.As.
+func (e *HTTPStatusError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrHTTPStatus}
+	}
+	return []error{ErrHTTPStatus, e.Err}
+}
+
+// rateLimitedError wraps the error of a 429 response with ErrRateLimited.
+// Returns other errors as is.
+func rateLimitedError(err error) error {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusTooManyRequests {
+		return fmt.Errorf("%w: %w", ErrRateLimited, err)
+	}
+	return err
+}
+
+// retryAfterOf returns the Retry-After delay of the HTTPStatusError.
+// Returns zero for other errors.
+func retryAfterOf(err error) time.Duration {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.RetryAfter
+	}
+	return 0
+}
+
+// AmountMismatchError represents a paid receipt with an amount different from the expected one.
+// errors.Is(err, ErrAmountMismatch) reports true for it.
+type AmountMismatchError struct {
+	ReceiptID string
+	// expected amount in tiyin
+	Expected int64
+	// paid amount in tiyin
+	Actual int64
+}
+
+// Error returns the receipt ID with expected and paid amounts as string.
+func (e *AmountMismatchError) Error() string {
+	return fmt.Sprintf("%v (receipt - %s expected - %d actual - %d)", ErrAmountMismatch, e.ReceiptID, e.Expected, e.Actual)
+}
+
+// Unwrap returns ErrAmountMismatch for errors.Is.
+func (e *AmountMismatchError) Unwrap() error {
+	return ErrAmountMismatch
+}
+
+// ValidationError represents an input rejected by client-side validation.
+// It keeps the sentinel error of the validator, so errors.Is(err, ErrInvalidAmount)
+// still works for errors returned by ValidateAmount.
+type ValidationError struct {
+	// input field name like amount or token
+	Field string
+	// offending value, masked for sensitive fields
+	Value string
+	// violated rule like "must be positive"
+	Rule string
+	// sentinel error of the validator
+	Err error
+}
+
+// Error returns the sentinel error with the field, value and rule as string.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%v (field - %s value - %q rule - %s)", e.Err, e.Field, e.Value, e.Rule)
+}
+
+// Unwrap returns the sentinel error of ValidationError for errors.Is and errors.As.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorToSentinel maps the PayMe error code to the sentinel error like ErrReceiptNotFound.
+// Unknown codes are mapped to ErrPaymeError.
+// Returns nil if the error is nil or has zero code.
+func ErrorToSentinel(e *Error) error {
+	if e == nil {
+		return nil
+	}
+
+	switch e.Code {
+	case InvalidAmountErrorCode:
+		return ErrInvalidAmount
+	case InvalidParamsErrorCode:
+		return ErrInvalidParams
+	case CardNotFoundErrorCode:
+		return ErrCardNotFound
+	case InvalidFormatTokenErrorCode:
+		return ErrInvalidFormatToken
+	case CardNumberNotFoundCode:
+		return ErrCardNumberNotFound
+	case CardExpiredCode:
+		return ErrCardExpired
+	case P2PIdenticalCardsErrorCode:
+		return ErrP2PIdenticalCards
+	case VerifyCodeSendFailedErrorCode:
+		return ErrVerifyCodeSendFailed
+	case InvalidVerifyCodeErrorCode:
+		return ErrInvalidVerifyCode
+	case ProcessingCenterNotAvailableCode:
+		return ErrProcessingCenterNotAvailable
+	case PaycomServiceNotAvailableCode:
+		return ErrPaycomServiceNotAvailable
+	case ReceiptNotFoundErrorCode:
+		return ErrReceiptNotFound
+	case ReceiptAlreadyPaidErrorCode:
+		return ErrReceiptAlreadyPaid
+	case ReceiptExpiredErrorCode:
+		return ErrReceiptExpired
+	case PermissionDeniedCode:
+		return ErrPermissionDenied
+	case ParseErrorCode:
+		return ErrParseError
+	case MethodNotFoundCode:
+		return ErrMethodNotFound
+	case InvalidRequestCode:
+		return ErrInvalidRequest
+	case 0:
+		return nil
 	default:
+		return ErrPaymeError
+	}
+}
+
+// ParseErrorResponse parses the PayMe error from a raw JSON-RPC response body,
+// e.g. a logged or proxied response.
+// Returns the error object, nil if the response has no error, or an error if the body is malformed.
+func ParseErrorResponse(body []byte) (*Error, error) {
+	// Validation
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, ErrEmptyResponse
+	}
+
+	var response Response
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("%w: json unmarshal error: %w, body - %s", ErrParseError, err, TruncateString(string(body), maxBodySnippet))
+	}
+
+	return response.Error, nil
+}
+
+// paymeErrors contains all sentinel errors mapped from PayMe error codes.
+var paymeErrors = []error{
+	ErrReceiptNotFound, ErrReceiptAlreadyPaid, ErrReceiptExpired,
+	ErrInvalidAmount, ErrInvalidParams, ErrCardNotFound, ErrInvalidFormatToken,
+	ErrCardNumberNotFound, ErrCardExpired, ErrP2PIdenticalCards,
+	ErrVerifyCodeSendFailed, ErrInvalidVerifyCode,
+	ErrPaycomServiceNotAvailable, ErrProcessingCenterNotAvailable,
+	ErrPermissionDenied, ErrParseError, ErrMethodNotFound, ErrInvalidRequest,
+	ErrUnableToCancel, ErrUnableToPerform,
+}
+
+// IsRetryable checks if the request may succeed if retried.
+// Timeouts, connection errors, unavailable PayMe services, 5xx and 429 statuses
+// and proxy error pages are retryable. Validation and PayMe business errors
+// like ErrReceiptAlreadyPaid, canceled or expired caller contexts, TLS and
+// certificate pinning failures and invalid URLs are terminal.
+// Returns true if the error is retryable, false otherwise.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, ErrTimeout) ||
+		errors.Is(err, ErrPaycomServiceNotAvailable) ||
+		errors.Is(err, ErrProcessingCenterNotAvailable) ||
+		errors.Is(err, ErrEmptyResponse) ||
+		errors.Is(err, ErrNonJSONResponse) {
+		return true
+	}
+
+	// Done caller context, retrying cannot succeed
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 		return false
 	}
+
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+
+	// Connection refused, reset or closed by the server before the response
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	// TLS and certificate errors are wrapped in *net.OpError as well
+	if errors.Is(err, ErrCertificateNotPinned) {
+		return false
+	}
+	var certErr *tls.CertificateVerificationError
+	if errors.As(err, &certErr) {
+		return false
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// IsPaymeError checks if the error is one of PayMe sentinel errors.
+// It uses errors.Is, so wrapped errors are also classified.
+// Returns true if the error matches a PayMe error, false otherwise.
+func IsPaymeError(err error) bool {
+	for _, paymeErr := range paymeErrors {
+		if errors.Is(err, paymeErr) {
+			return true
+		}
+	}
+	return false
 }
 
 // GetErrorCode extracts the PayMe error code from a custom error.
-// It checks if the error is a PayMeError and returns its code.
-// Returns the error code as int, or 0 if not a PayMeError.
+// It checks if the error is a PaymeError and returns its code,
+// otherwise it matches wrapped sentinel errors with errors.Is.
+// Returns the error code as int, or 0 if not a PayMe error.
 func GetErrorCode(err error) int {
-	switch err {
-	case ErrInvalidAmount:
+	var paymeErr *PaymeError
+	if errors.As(err, &paymeErr) {
+		return paymeErr.Code
+	}
+
+	switch {
+	case errors.Is(err, ErrInvalidAmount):
 		return InvalidAmountErrorCode
-	case ErrInvalidParams:
+	case errors.Is(err, ErrInvalidParams):
 		return InvalidParamsErrorCode
-	case ErrReceiptNotFound:
+	case errors.Is(err, ErrReceiptNotFound):
 		return ReceiptNotFoundErrorCode
-	case ErrReceiptAlreadyPaid:
+	case errors.Is(err, ErrReceiptAlreadyPaid):
 		return ReceiptAlreadyPaidErrorCode
-	case ErrReceiptExpired:
+	case errors.Is(err, ErrReceiptExpired):
 		return ReceiptExpiredErrorCode
-	case ErrCardNotFound:
+	case errors.Is(err, ErrCardNotFound):
 		return CardNotFoundErrorCode
-	case ErrInvalidFormatToken:
+	case errors.Is(err, ErrInvalidFormatToken):
 		return InvalidFormatTokenErrorCode
-	case ErrCardNumberNotFound:
+	case errors.Is(err, ErrCardNumberNotFound):
 		return CardNumberNotFoundCode
-	case ErrCardExpired:
+	case errors.Is(err, ErrCardExpired):
 		return CardExpiredCode
-	case ErrP2PIdenticalCards:
+	case errors.Is(err, ErrP2PIdenticalCards):
 		return P2PIdenticalCardsErrorCode
-	case ErrPaycomServiceNotAvailable:
+	case errors.Is(err, ErrVerifyCodeSendFailed):
+		return VerifyCodeSendFailedErrorCode
+	case errors.Is(err, ErrInvalidVerifyCode):
+		return InvalidVerifyCodeErrorCode
+	case errors.Is(err, ErrPaycomServiceNotAvailable):
 		return PaycomServiceNotAvailableCode
-	case ErrProcessingCenterNotAvailable:
+	case errors.Is(err, ErrProcessingCenterNotAvailable):
 		return ProcessingCenterNotAvailableCode
-	case ErrPermissionDenied:
+	case errors.Is(err, ErrPermissionDenied):
 		return PermissionDeniedCode
-	case ErrParseError:
+	case errors.Is(err, ErrParseError):
 		return ParseErrorCode
-	case ErrMethodNotFound:
+	case errors.Is(err, ErrMethodNotFound):
 		return MethodNotFoundCode
-	case ErrInvalidRequest:
+	case errors.Is(err, ErrInvalidRequest):
 		return InvalidRequestCode
+	case errors.Is(err, ErrUnableToCancel):
+		return UnableToCancelErrorCode
+	case errors.Is(err, ErrUnableToPerform):
+		return UnableToPerformErrorCode
 	default:
 		return 0
 	}
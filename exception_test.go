@@ -0,0 +1,232 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// wrapTwice wraps the error in two layers like a service and a handler would.
+func wrapTwice(err error) error {
+	return fmt.Errorf("handler: %w", fmt.Errorf("service: %w", err))
+}
+
+func TestErrorHelpersThroughTwoWrapLayers(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		isPayme  bool
+		code     int
+		sentinel error
+	}{
+		{
+			name:     "PaymeError",
+			err:      &PaymeError{Code: ReceiptAlreadyPaidErrorCode, Message: "paid", Err: ErrReceiptAlreadyPaid},
+			isPayme:  true,
+			code:     ReceiptAlreadyPaidErrorCode,
+			sentinel: ErrReceiptAlreadyPaid,
+		},
+		{
+			name:     "sentinel",
+			err:      ErrCardExpired,
+			isPayme:  true,
+			code:     CardExpiredCode,
+			sentinel: ErrCardExpired,
+		},
+		{
+			name:     "PaymeError with unknown code",
+			err:      &PaymeError{Code: -31999, Err: ErrPaymeError},
+			isPayme:  false,
+			code:     -31999,
+			sentinel: ErrPaymeError,
+		},
+		{
+			name:     "non PayMe error",
+			err:      errors.New("disk is full"),
+			isPayme:  false,
+			code:     0,
+			sentinel: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := wrapTwice(tt.err)
+
+			if got := IsPaymeError(err); got != tt.isPayme {
+				t.Errorf("IsPaymeError = %t, want %t", got, tt.isPayme)
+			}
+			if got := GetErrorCode(err); got != tt.code {
+				t.Errorf("GetErrorCode = %d, want %d", got, tt.code)
+			}
+			if tt.sentinel != nil && !errors.Is(err, tt.sentinel) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.sentinel)
+			}
+		})
+	}
+}
+
+func TestPaymeErrorAsThroughTwoWrapLayers(t *testing.T) {
+	err := wrapTwice(&PaymeError{Code: InvalidParamsErrorCode, Data: "amount", Origin: "receipts.create", Err: ErrInvalidParams})
+
+	var paymeErr *PaymeError
+	if !errors.As(err, &paymeErr) {
+		t.Fatalf("errors.As(%v) = false", err)
+	}
+	if paymeErr.Data != "amount" || paymeErr.Origin != "receipts.create" {
+		t.Errorf("PaymeError = %+v, want data and origin kept", paymeErr)
+	}
+}
+
+func TestTimeoutThroughTwoWrapLayers(t *testing.T) {
+	err := wrapTwice(fmt.Errorf("%w: %w", ErrTimeout, context.DeadlineExceeded))
+
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("errors.Is(%v, ErrTimeout) = false", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("errors.Is(%v, context.DeadlineExceeded) = false", err)
+	}
+	if !IsRetryable(err) {
+		t.Errorf("IsRetryable(%v) = false", err)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"timeout", fmt.Errorf("%w: %w", ErrTimeout, context.DeadlineExceeded), true},
+		{"network error", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, true},
+		{"wrapped dial error", fmt.Errorf("http request error: %w", &url.Error{Op: "Post", URL: "https://checkout.test.paycom.uz/api", Err: &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "checkout.test.paycom.uz"}}}), true},
+		{"connection reset", fmt.Errorf("http request error: %w", &url.Error{Op: "Post", URL: "https://checkout.test.paycom.uz/api", Err: syscall.ECONNRESET}), true},
+		{"network timeout", &url.Error{Op: "Post", URL: "https://checkout.test.paycom.uz/api", Err: &net.DNSError{Err: "i/o timeout", IsTimeout: true}}, true},
+		{"paycom service unavailable", &PaymeError{Code: PaycomServiceNotAvailableCode, Err: ErrPaycomServiceNotAvailable}, true},
+		{"processing center unavailable", ErrProcessingCenterNotAvailable, true},
+		{"empty body", &HTTPStatusError{StatusCode: http.StatusOK, Err: ErrEmptyResponse}, true},
+		{"proxy error page", &HTTPStatusError{StatusCode: http.StatusOK, Err: ErrNonJSONResponse}, true},
+		{"status 502", &HTTPStatusError{StatusCode: http.StatusBadGateway}, true},
+		{"status 429", &HTTPStatusError{StatusCode: http.StatusTooManyRequests}, true},
+		{"status 400", &HTTPStatusError{StatusCode: http.StatusBadRequest}, false},
+		{"validation", &ValidationError{Field: "amount", Rule: "must be positive", Err: ErrInvalidAmount}, false},
+		{"already paid", &PaymeError{Code: ReceiptAlreadyPaidErrorCode, Err: ErrReceiptAlreadyPaid}, false},
+		{"card expired", wrapTwice(ErrCardExpired), false},
+		{"canceled context", context.Canceled, false},
+		{"canceled request", fmt.Errorf("http request error: %w", &url.Error{Op: "Post", URL: "https://checkout.test.paycom.uz/api", Err: context.Canceled}), false},
+		{"caller deadline", fmt.Errorf("http request error: %w", &url.Error{Op: "Post", URL: "https://checkout.test.paycom.uz/api", Err: context.DeadlineExceeded}), false},
+		{"certificate not pinned", &url.Error{Op: "Post", URL: "https://checkout.test.paycom.uz/api", Err: &net.OpError{Op: "remote error", Err: ErrCertificateNotPinned}}, false},
+		{"invalid url", &url.Error{Op: "Post", URL: "://bad", Err: errors.New("missing protocol scheme")}, false},
+		{"other", errors.New("disk is full"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %t, want %t", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanceledContextIsNotRetried(t *testing.T) {
+	var calls atomic.Int64
+	server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		return receiptResult(Receipt{ID: "receipt-1"}), nil
+	})
+	client := newTestClient(t, server.URL, func(config *ClientConfig) {
+		config.MaxRetries = 3
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	_, err := client.GetReceipt(ctx, "receipt-1")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want context.Canceled", err)
+	}
+	if errors.Is(err, ErrTimeout) || IsRetryable(err) {
+		t.Errorf("error = %v, want terminal error", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("requests = %d, want 1", calls.Load())
+	}
+}
+
+func TestValidationErrorMessage(t *testing.T) {
+	err := wrapTwice(&ValidationError{Field: "amount", Value: "-5", Rule: "must be positive", Err: ErrInvalidAmount})
+
+	want := `handler: service: ` + ErrInvalidAmount.Error() + ` (field - amount value - "-5" rule - must be positive)`
+	if err.Error() != want {
+		t.Errorf("Error() = %s, want %s", err, want)
+	}
+	if !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("error = %v, want ErrInvalidAmount", err)
+	}
+}
+
+func TestParseErrorResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		code     int
+		sentinel error
+		wantErr  error
+	}{
+		{"error", `{"jsonrpc":"2.0","id":"1","error":{"code":-31401,"message":"Receipt not found"}}`, ReceiptNotFoundErrorCode, ErrReceiptNotFound, nil},
+		{"unknown code", `{"id":"1","error":{"code":-31999,"message":"custom"}}`, -31999, ErrPaymeError, nil},
+		{"result", `{"id":"1","result":{"receipt":{}}}`, 0, nil, nil},
+		{"empty", "", 0, nil, ErrEmptyResponse},
+		{"whitespace", " \n", 0, nil, ErrEmptyResponse},
+		{"malformed", `{"error":{"code":`, 0, nil, ErrParseError},
+		{"html", `<html>502 Bad Gateway</html>`, 0, nil, ErrParseError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rpcErr, err := ParseErrorResponse([]byte(tt.body))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseErrorResponse error: %v", err)
+			}
+
+			if tt.code == 0 {
+				if rpcErr != nil {
+					t.Errorf("error object = %+v, want nil", rpcErr)
+				}
+				return
+			}
+			if rpcErr == nil || rpcErr.Code != tt.code {
+				t.Fatalf("error object = %+v, want code %d", rpcErr, tt.code)
+			}
+			if got := ErrorToSentinel(rpcErr); got != tt.sentinel {
+				t.Errorf("ErrorToSentinel = %v, want %v", got, tt.sentinel)
+			}
+		})
+	}
+}
+
+func TestErrorToSentinelWithoutCode(t *testing.T) {
+	if got := ErrorToSentinel(nil); got != nil {
+		t.Errorf("ErrorToSentinel(nil) = %v, want nil", got)
+	}
+	if got := ErrorToSentinel(&Error{Message: "no code"}); got != nil {
+		t.Errorf("ErrorToSentinel(zero code) = %v, want nil", got)
+	}
+}
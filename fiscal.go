@@ -0,0 +1,56 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+)
+
+// FiscalStatus represents the OFD registration status of the receipt fiscal data.
+type FiscalStatus int
+
+const (
+	FiscalStatusPending    FiscalStatus = iota // fiscal data is not set yet
+	FiscalStatusRegistered                     // fiscal receipt is registered in OFD
+	FiscalStatusFailed                         // OFD rejected the fiscal receipt
+)
+
+// String returns the name of the fiscal status.
+func (s FiscalStatus) String() string {
+	switch s {
+	case FiscalStatusPending:
+		return "pending"
+	case FiscalStatusRegistered:
+		return "registered"
+	case FiscalStatusFailed:
+		return "failed"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// FiscalStatus returns the fiscal status of the receipt by its latest fiscal data.
+// OFD status code 0 means registered, any other code means failed.
+func (r *Receipt) FiscalStatus() FiscalStatus {
+	if len(r.FiscalData) == 0 {
+		return FiscalStatusPending
+	}
+	if r.FiscalData[len(r.FiscalData)-1].StatusCode != 0 {
+		return FiscalStatusFailed
+	}
+	return FiscalStatusRegistered
+}
+
+// GetFiscalStatus retrieves the fiscal status of the receipt after SetFiscalData.
+// It calls GetReceipt and parses the receipt fiscal data, so it can be polled until registered.
+// Returns FiscalStatus or an error.
+func (c *Client) GetFiscalStatus(ctx context.Context, receiptID string, opts ...RequestOption) (FiscalStatus, error) {
+	resp, err := c.GetReceipt(ctx, receiptID, opts...)
+	if err != nil {
+		return FiscalStatusPending, err
+	}
+	if resp.Receipt == nil {
+		return FiscalStatusPending, ErrReceiptNotFound
+	}
+
+	return resp.Receipt.FiscalStatus(), nil
+}
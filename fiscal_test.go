@@ -0,0 +1,50 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGetFiscalStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		fiscalData FiscalDataList
+		want       FiscalStatus
+	}{
+		{"pending", nil, FiscalStatusPending},
+		{"registered", FiscalDataList{{ReceiptID: 1, StatusCode: 0}}, FiscalStatusRegistered},
+		{"failed after registered", FiscalDataList{{ReceiptID: 1}, {ReceiptID: 2, StatusCode: 7}}, FiscalStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+				return receiptResult(Receipt{ID: call.Params["id"].(string), FiscalData: tt.fiscalData}), nil
+			})
+			client := newTestClient(t, server.URL)
+
+			got, err := client.GetFiscalStatus(context.Background(), "receipt-1")
+			if err != nil {
+				t.Fatalf("GetFiscalStatus error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("status = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetFiscalStatusForwardsOptions(t *testing.T) {
+	server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+		time.Sleep(100 * time.Millisecond)
+		return receiptResult(Receipt{ID: "receipt-1"}), nil
+	})
+	client := newTestClient(t, server.URL)
+
+	_, err := client.GetFiscalStatus(context.Background(), "receipt-1", WithTimeout(10*time.Millisecond))
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("error = %v, want ErrTimeout", err)
+	}
+}
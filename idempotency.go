@@ -0,0 +1,117 @@
+package payment
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// IdempotencyCache stores idempotency keys of PayReceiptWithKey calls.
+// Implementations must be safe for concurrent use, so they can be backed
+// by shared storage like Redis when several instances process payments.
+type IdempotencyCache interface {
+	// Begin marks the key as in-flight.
+	// Returns the cached response if the key is completed,
+	// or ErrIdempotencyKeyInFlight if the key is being processed.
+	Begin(ctx context.Context, key string) (*PayReceiptResponse, error)
+	// Complete stores the response for the key.
+	Complete(ctx context.Context, key string, resp *PayReceiptResponse) error
+	// Abort releases the in-flight key so the payment can be retried.
+	Abort(ctx context.Context, key string) error
+}
+
+// MemoryIdempotencyCache is the default in-memory IdempotencyCache.
+// It keeps at most maxEntries keys, each for ttl duration. When full,
+// expired keys are removed first and then the oldest key is evicted,
+// so memory usage is bounded by maxEntries cached responses.
+type MemoryIdempotencyCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]*idempotencyEntry
+}
+
+// idempotencyEntry contains the state of a single idempotency key.
+type idempotencyEntry struct {
+	resp      *PayReceiptResponse
+	createdAt time.Time
+}
+
+// NewMemoryIdempotencyCache creates a new in-memory idempotency cache.
+// Returns a pointer to MemoryIdempotencyCache with the provided ttl and size limit.
+func NewMemoryIdempotencyCache(ttl time.Duration, maxEntries int) *MemoryIdempotencyCache {
+	return &MemoryIdempotencyCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*idempotencyEntry),
+	}
+}
+
+// Begin marks the key as in-flight or returns the state of the existing key.
+// Returns the cached response, ErrIdempotencyKeyInFlight, or nil for a new key.
+func (m *MemoryIdempotencyCache) Begin(_ context.Context, key string) (*PayReceiptResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	if entry, ok := m.entries[key]; ok && now.Sub(entry.createdAt) < m.ttl {
+		if entry.resp == nil {
+			return nil, ErrIdempotencyKeyInFlight
+		}
+		return entry.resp, nil
+	}
+
+	if len(m.entries) >= m.maxEntries {
+		m.evict(now)
+	}
+
+	m.entries[key] = &idempotencyEntry{createdAt: now}
+
+	return nil, nil
+}
+
+// Complete stores the response for the in-flight key.
+func (m *MemoryIdempotencyCache) Complete(_ context.Context, key string, resp *PayReceiptResponse) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if entry, ok := m.entries[key]; ok {
+		entry.resp = resp
+	}
+
+	return nil
+}
+
+// Abort removes the in-flight key.
+func (m *MemoryIdempotencyCache) Abort(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+
+	return nil
+}
+
+// evict removes expired keys, and the oldest key if the cache is still full.
+func (m *MemoryIdempotencyCache) evict(now time.Time) {
+	var (
+		oldestKey  string
+		oldestTime time.Time
+	)
+
+	for key, entry := range m.entries {
+		if now.Sub(entry.createdAt) >= m.ttl {
+			delete(m.entries, key)
+			continue
+		}
+		if oldestKey == "" || entry.createdAt.Before(oldestTime) {
+			oldestKey = key
+			oldestTime = entry.createdAt
+		}
+	}
+
+	if len(m.entries) >= m.maxEntries && oldestKey != "" {
+		delete(m.entries, oldestKey)
+	}
+}
@@ -0,0 +1,27 @@
+package payment
+
+import "context"
+
+// PaymeClient contains the public receipt and card operations of PayMe API.
+// *Client implements it, and paymetest.MockClient can be used instead in unit tests.
+type PaymeClient interface {
+	// receipts
+	CreateReceipt(ctx context.Context, amount int64, account map[string]interface{}, description string, detail map[string]interface{}, opts ...RequestOption) (*CreateReceiptResponse, error)
+	PayReceipt(ctx context.Context, receiptID, token string, opts ...RequestOption) (*PayReceiptResponse, error)
+	SendReceipt(ctx context.Context, receiptID string, opts ...RequestOption) (*SendReceiptResponse, error)
+	CancelReceipt(ctx context.Context, receiptID string, opts ...RequestOption) (*CancelReceiptResponse, error)
+	CheckReceipt(ctx context.Context, receiptID string, opts ...RequestOption) (*CheckReceiptResponse, error)
+	GetReceipt(ctx context.Context, receiptID string, opts ...RequestOption) (*GetReceiptResponse, error)
+	GetAllReceipts(ctx context.Context, from, to int64, count int, opts ...RequestOption) (*GetAllReceiptsResponse, error)
+	SetFiscalData(ctx context.Context, receiptID string, fiscalData map[string]interface{}, opts ...RequestOption) (*SetFiscalDataResponse, error)
+
+	// cards
+	CreateCard(ctx context.Context, cardNumber, expire string, save bool, opts ...RequestOption) (*CreateCardResponse, error)
+	GetCardVerifyCode(ctx context.Context, token string, opts ...RequestOption) (*GetVerifyCodeResponse, error)
+	VerifyCard(ctx context.Context, token, code string, opts ...RequestOption) (*VerifyCardResponse, error)
+	CheckCard(ctx context.Context, token string, opts ...RequestOption) (*CheckCardResponse, error)
+	RemoveCard(ctx context.Context, token string, opts ...RequestOption) (*RemoveCardResponse, error)
+}
+
+// Client must implement PaymeClient
+var _ PaymeClient = (*Client)(nil)
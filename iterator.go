@@ -0,0 +1,218 @@
+package payment
+
+import (
+	"context"
+	"time"
+)
+
+// ReceiptIterator pages through receipts.get_all results.
+// Each next page starts at the latest create_time of the previous page,
+// and receipts already returned at that boundary are skipped,
+// so there are no duplicate or skipped receipts.
+// A ReceiptIterator is not safe for concurrent use.
+type ReceiptIterator struct {
+	ctx      context.Context
+	client   *Client
+	from     int64
+	to       int64
+	pageSize int
+
+	page []*Receipt
+	pos  int
+	seen map[string]struct{}
+	done bool
+	err  error
+}
+
+// IterateReceipts creates an iterator over receipts within the time range.
+// Receipts are fetched lazily in pages of pageSize (default 50).
+// Returns a pointer to ReceiptIterator.
+func (c *Client) IterateReceipts(ctx context.Context, from, to time.Time, pageSize int) *ReceiptIterator {
+	if pageSize <= 0 {
+		pageSize = 50
+	}
+
+	return &ReceiptIterator{
+		ctx:      ctx,
+		client:   c,
+		from:     from.UnixMilli(),
+		to:       to.UnixMilli(),
+		pageSize: pageSize,
+		seen:     make(map[string]struct{}),
+	}
+}
+
+// Next returns the next receipt, fetching the next page when needed.
+// Returns false when there are no more receipts or an error occurred, check Err after.
+func (it *ReceiptIterator) Next() (*Receipt, bool) {
+	for it.pos >= len(it.page) {
+		if it.done || it.err != nil {
+			return nil, false
+		}
+		it.fetch()
+	}
+
+	receipt := it.page[it.pos]
+	it.pos++
+
+	return receipt, true
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *ReceiptIterator) Err() error {
+	return it.err
+}
+
+// fetch loads the next page and moves the window boundary.
+func (it *ReceiptIterator) fetch() {
+	resp, err := it.client.GetAllReceipts(it.ctx, it.from, it.to, it.pageSize)
+	if err != nil {
+		it.err = err
+		return
+	}
+
+	receipts := resp.Receipts
+
+	// Last page
+	if len(receipts) < it.pageSize {
+		it.done = true
+	}
+
+	boundary := it.from
+	page := make([]*Receipt, 0, len(receipts))
+	for _, receipt := range receipts {
+		if _, ok := it.seen[receipt.ID]; ok {
+			continue
+		}
+		page = append(page, receipt)
+		if receipt.CreateTime > boundary {
+			boundary = receipt.CreateTime
+		}
+	}
+
+	// Remember receipts at the new boundary to skip them on the next page
+	seen := make(map[string]struct{})
+	if boundary == it.from {
+		seen = it.seen
+	}
+	for _, receipt := range receipts {
+		if receipt.CreateTime == boundary {
+			seen[receipt.ID] = struct{}{}
+		}
+	}
+
+	// Full page without new receipts, the window cannot move further
+	if len(page) == 0 {
+		it.done = true
+	}
+
+	it.from = boundary
+	it.seen = seen
+	it.page = page
+	it.pos = 0
+}
+
+// TransactionIterator pages through transactions.get_all results
+// the same way ReceiptIterator pages through receipts.
+// A TransactionIterator is not safe for concurrent use.
+type TransactionIterator struct {
+	ctx      context.Context
+	client   *Client
+	from     int64
+	to       int64
+	pageSize int
+
+	page []*Transaction
+	pos  int
+	seen map[string]struct{}
+	done bool
+	err  error
+}
+
+// IterateTransactions creates an iterator over transactions within the time range.
+// Transactions are fetched lazily in pages of pageSize (default 50).
+// Returns a pointer to TransactionIterator.
+func (c *Client) IterateTransactions(ctx context.Context, from, to time.Time, pageSize int) *TransactionIterator {
+	if pageSize <= 0 {
+		pageSize = 50
+	}
+
+	return &TransactionIterator{
+		ctx:      ctx,
+		client:   c,
+		from:     from.UnixMilli(),
+		to:       to.UnixMilli(),
+		pageSize: pageSize,
+		seen:     make(map[string]struct{}),
+	}
+}
+
+// Next returns the next transaction, fetching the next page when needed.
+// Returns false when there are no more transactions or an error occurred, check Err after.
+func (it *TransactionIterator) Next() (*Transaction, bool) {
+	for it.pos >= len(it.page) {
+		if it.done || it.err != nil {
+			return nil, false
+		}
+		it.fetch()
+	}
+
+	transaction := it.page[it.pos]
+	it.pos++
+
+	return transaction, true
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *TransactionIterator) Err() error {
+	return it.err
+}
+
+// fetch loads the next page and moves the window boundary.
+func (it *TransactionIterator) fetch() {
+	resp, err := it.client.GetAllTransactions(it.ctx, it.from, it.to, it.pageSize)
+	if err != nil {
+		it.err = err
+		return
+	}
+
+	transactions := resp.Transactions
+
+	// Last page
+	if len(transactions) < it.pageSize {
+		it.done = true
+	}
+
+	boundary := it.from
+	page := make([]*Transaction, 0, len(transactions))
+	for _, transaction := range transactions {
+		if _, ok := it.seen[transaction.ID]; ok {
+			continue
+		}
+		page = append(page, transaction)
+		if transaction.CreateTime > boundary {
+			boundary = transaction.CreateTime
+		}
+	}
+
+	// Remember transactions at the new boundary to skip them on the next page
+	seen := make(map[string]struct{})
+	if boundary == it.from {
+		seen = it.seen
+	}
+	for _, transaction := range transactions {
+		if transaction.CreateTime == boundary {
+			seen[transaction.ID] = struct{}{}
+		}
+	}
+
+	// Full page without new transactions, the window cannot move further
+	if len(page) == 0 {
+		it.done = true
+	}
+
+	it.from = boundary
+	it.seen = seen
+	it.page = page
+	it.pos = 0
+}
@@ -0,0 +1,41 @@
+package payment
+
+import (
+	"context"
+	"log/slog"
+)
+
+// logRequest writes a structured log record for the PayMe API call.
+// Successful calls are logged at info level and failed calls at error level.
+func (c *Client) logRequest(ctx context.Context, method, requestID string, params interface{}, err error) {
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("request_id", requestID),
+	}
+
+	if receiptID := receiptIDFromParams(method, params); receiptID != "" {
+		attrs = append(attrs, slog.String("receipt_id", receiptID))
+	}
+
+	if paramsMap, ok := params.(map[string]interface{}); ok {
+		if account, ok := paramsMap["account"].(map[string]interface{}); ok {
+			attrs = append(attrs, slog.Any("account", c.maskAccount(account)))
+		}
+	}
+
+	if err != nil {
+		attrs = append(attrs,
+			slog.Int("error_code", GetErrorCode(err)),
+			slog.String("error", c.redact(err.Error())),
+		)
+		c.SlogLogger.LogAttrs(ctx, slog.LevelError, "payme request failed", attrs...)
+		return
+	}
+
+	c.SlogLogger.LogAttrs(ctx, slog.LevelInfo, "payme request", attrs...)
+}
+
+// maskAccount masks the receipt account with the client SensitiveAccountKeys.
+func (c *Client) maskAccount(account map[string]interface{}) map[string]interface{} {
+	return MaskAccount(account, c.SensitiveAccountKeys...)
+}
@@ -0,0 +1,573 @@
+package payment
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ===== MERCHANT API METHODS =====
+
+const (
+	MerchantMethodCheckPerformTransaction = "CheckPerformTransaction"
+	MerchantMethodCreateTransaction       = "CreateTransaction"
+	MerchantMethodPerformTransaction      = "PerformTransaction"
+	MerchantMethodCancelTransaction       = "CancelTransaction"
+	MerchantMethodCheckTransaction        = "CheckTransaction"
+	MerchantMethodGetStatement            = "GetStatement"
+)
+
+// ===== TRANSACTION CANCEL REASONS =====
+
+const (
+	CancelReasonReceiverNotFound = 1 // one or more receivers not found or inactive
+	CancelReasonDebitError       = 2 // debit operation error in processing center
+	CancelReasonTransactionError = 3 // transaction execution error
+	CancelReasonTimeout          = 4 // transaction canceled by timeout
+	CancelReasonRefund           = 5 // refund
+)
+
+// MerchantService is implemented by merchants to handle PayMe Merchant API callbacks.
+// Each method receives decoded params and returns a result or an error.
+// Returning *MerchantError or *Error allows to reply with a specific PayMe error code,
+// and sentinels like ErrInvalidAmount or ErrTransactionNotFound are mapped to Merchant API codes.
+type MerchantService interface {
+	// CheckPerformTransaction checks if the transaction can be performed for the account.
+	CheckPerformTransaction(ctx context.Context, params CheckPerformTransactionParams) (*CheckPerformTransactionResult, error)
+	// CreateTransaction persists a new transaction for the account.
+	CreateTransaction(ctx context.Context, params CreateTransactionParams) (*CreateTransactionResult, error)
+	// PerformTransaction marks the transaction as paid.
+	PerformTransaction(ctx context.Context, params PerformTransactionParams) (*PerformTransactionResult, error)
+	// CancelTransaction cancels created or performed transaction.
+	CancelTransaction(ctx context.Context, params CancelTransactionParams) (*CancelTransactionResult, error)
+	// CheckTransaction returns the current state of the transaction.
+	CheckTransaction(ctx context.Context, params CheckTransactionParams) (*CheckTransactionResult, error)
+	// GetStatement returns transactions created within from and to timestamps in milliseconds.
+	GetStatement(ctx context.Context, from, to int64) ([]StatementEntry, error)
+}
+
+// MerchantHandler is the http.Handler for PayMe Merchant API callbacks.
+// It validates authentication, parses JSON-RPC requests and dispatches
+// them to the configured MerchantService.
+// The handler is safe for concurrent use, and Service methods run concurrently for different requests.
+type MerchantHandler struct {
+	// merchant key for X-Auth header validation
+	MerchantKey string
+	// service handling merchant callbacks
+	Service MerchantService
+	// logger
+	Logger *log.Logger
+	// account errors mapped to PayMe codes in range -31099..-31050
+	AccountErrors map[error]int
+	// max request body size in bytes, default 1 MB
+	MaxRequestBytes int64
+	// known transactions for idempotency and state checks, default in-memory store with 100000 transactions
+	Store TransactionStore
+
+	// guards lazy initialization of Store and transaction locks
+	mu    sync.Mutex
+	locks map[string]*transactionLock
+}
+
+// transactionLock serializes requests for one PayMe transaction id.
+type transactionLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// defaultMaxMerchantTransactions is the size limit of the default in-memory transaction store.
+const defaultMaxMerchantTransactions = 100000
+
+// merchantRequest represents the incoming JSON-RPC request from PayMe.
+// ID is kept raw because PayMe sends it as a number.
+type merchantRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// merchantResponse represents the JSON-RPC reply to PayMe.
+// ID is the raw request id echoed unchanged, so numeric ids stay numbers.
+type merchantResponse struct {
+	Jsonrpc string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *MerchantError  `json:"error,omitempty"`
+}
+
+// defaultMaxMerchantRequestBytes is the default max size of Merchant API request body.
+const defaultMaxMerchantRequestBytes = 1 << 20
+
+// NewMerchantHandler creates a new Merchant API handler with the provided key and service.
+// ErrOrderHasTransaction is mapped to AccountErrorCodeMax by default.
+// Returns a pointer to MerchantHandler ready to be mounted on a webhook endpoint.
+func NewMerchantHandler(merchantKey string, service MerchantService) *MerchantHandler {
+	return &MerchantHandler{
+		MerchantKey: merchantKey,
+		Service:     service,
+		AccountErrors: map[error]int{
+			ErrOrderHasTransaction: AccountErrorCodeMax,
+		},
+		Store: NewMemoryTransactionStore(defaultMaxMerchantTransactions),
+	}
+}
+
+// ServeHTTP handles incoming PayMe Merchant API requests.
+// It checks the X-Auth or Authorization header, decodes the request and writes the JSON-RPC reply.
+// Unauthenticated requests are rejected before the body is read.
+func (h *MerchantHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeResponse(w, merchantResponse{Error: newMerchantError(InvalidRequestCode)})
+		return
+	}
+
+	// Check authentication
+	if !h.isAuthorized(r) {
+		h.writeResponse(w, merchantResponse{Error: newMerchantError(PermissionDeniedCode)})
+		return
+	}
+
+	maxBytes := h.MaxRequestBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxMerchantRequestBytes
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBytes))
+	if err != nil {
+		h.writeResponse(w, merchantResponse{Error: newMerchantError(ParseErrorCode)})
+		return
+	}
+
+	var request merchantRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		h.writeResponse(w, merchantResponse{Error: newMerchantError(ParseErrorCode)})
+		return
+	}
+
+	result, err := h.dispatch(r.Context(), request)
+	if err != nil {
+		if h.Logger != nil {
+			h.Logger.Printf("PayMe merchant method - %s request-id - %s error - %v", request.Method, request.ID, err)
+		}
+		h.writeResponse(w, merchantResponse{ID: request.ID, Error: h.toMerchantError(err)})
+		return
+	}
+
+	h.writeResponse(w, merchantResponse{ID: request.ID, Result: result})
+}
+
+// dispatch decodes the params and calls the service method for the request.
+// Returns the method result or an error.
+func (h *MerchantHandler) dispatch(ctx context.Context, request merchantRequest) (interface{}, error) {
+	switch request.Method {
+	case MerchantMethodCheckPerformTransaction:
+		var params CheckPerformTransactionParams
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return nil, ErrInvalidRequest
+		}
+		return h.Service.CheckPerformTransaction(ctx, params)
+	case MerchantMethodCreateTransaction:
+		var params CreateTransactionParams
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return nil, ErrInvalidRequest
+		}
+		return h.createTransaction(ctx, params)
+	case MerchantMethodPerformTransaction:
+		var params PerformTransactionParams
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return nil, ErrInvalidRequest
+		}
+		return h.performTransaction(ctx, params)
+	case MerchantMethodCancelTransaction:
+		var params CancelTransactionParams
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return nil, ErrInvalidRequest
+		}
+		return h.cancelTransaction(ctx, params)
+	case MerchantMethodCheckTransaction:
+		var params CheckTransactionParams
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return nil, ErrInvalidRequest
+		}
+		return h.Service.CheckTransaction(ctx, params)
+	case MerchantMethodGetStatement:
+		var params GetStatementParams
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return nil, ErrInvalidRequest
+		}
+		return h.getStatement(ctx, params)
+	default:
+		return nil, ErrMethodNotFound
+	}
+}
+
+// createTransaction calls the service CreateTransaction method only once per PayMe transaction id.
+// Repeated requests with the same id return the previously stored result. Concurrent requests
+// are serialized by the transaction lock, and SaveIfAbsent keeps the first result if another
+// handler sharing the store created the transaction meanwhile.
+// Returns CreateTransactionResult or an error.
+func (h *MerchantHandler) createTransaction(ctx context.Context, params CreateTransactionParams) (*CreateTransactionResult, error) {
+	defer h.lockTransaction(params.ID)()
+
+	store := h.transactionStore()
+
+	tx, ok, err := store.Get(ctx, params.ID)
+	if err != nil {
+		return nil, fmt.Errorf("transaction store get error: %w", err)
+	}
+	if ok {
+		return &CreateTransactionResult{
+			CreateTime:  tx.CreateTime,
+			Transaction: tx.Transaction,
+			State:       tx.State,
+		}, nil
+	}
+
+	result, err := h.Service.CreateTransaction(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, saved, err := store.SaveIfAbsent(ctx, &MerchantTransaction{
+		ID:          params.ID,
+		Transaction: result.Transaction,
+		CreateTime:  result.CreateTime,
+		State:       result.State,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("transaction store save error: %w", err)
+	}
+	if !saved {
+		return &CreateTransactionResult{
+			CreateTime:  tx.CreateTime,
+			Transaction: tx.Transaction,
+			State:       tx.State,
+		}, nil
+	}
+
+	return result, nil
+}
+
+// performTransaction calls the service PerformTransaction method for a created transaction.
+// Already performed transactions return the stored result, and transactions
+// in any other state are rejected with UnableToPerformErrorCode.
+// Returns PerformTransactionResult or an error.
+func (h *MerchantHandler) performTransaction(ctx context.Context, params PerformTransactionParams) (*PerformTransactionResult, error) {
+	defer h.lockTransaction(params.ID)()
+
+	store := h.transactionStore()
+
+	tx, ok, err := store.Get(ctx, params.ID)
+	if err != nil {
+		return nil, fmt.Errorf("transaction store get error: %w", err)
+	}
+	if ok {
+		switch TransactionState(tx.State) {
+		case TransactionStateCreated:
+		case TransactionStateCompleted:
+			return &PerformTransactionResult{
+				Transaction: tx.Transaction,
+				PerformTime: tx.PerformTime,
+				State:       tx.State,
+			}, nil
+		default:
+			return nil, ErrUnableToPerform
+		}
+	}
+
+	result, err := h.Service.PerformTransaction(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		tx.PerformTime = result.PerformTime
+		tx.State = result.State
+		if err := store.Save(ctx, tx); err != nil {
+			return nil, fmt.Errorf("transaction store save error: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// cancelTransaction calls the service CancelTransaction method for created or performed transaction.
+// Created transaction moves to state -1 and performed transaction moves to state -2.
+// Already canceled transactions return the stored result.
+// Returns CancelTransactionResult or an error.
+func (h *MerchantHandler) cancelTransaction(ctx context.Context, params CancelTransactionParams) (*CancelTransactionResult, error) {
+	defer h.lockTransaction(params.ID)()
+
+	store := h.transactionStore()
+
+	tx, ok, err := store.Get(ctx, params.ID)
+	if err != nil {
+		return nil, fmt.Errorf("transaction store get error: %w", err)
+	}
+	if ok && TransactionState(tx.State).IsCanceled() {
+		return &CancelTransactionResult{
+			Transaction: tx.Transaction,
+			CancelTime:  tx.CancelTime,
+			State:       tx.State,
+		}, nil
+	}
+
+	result, err := h.Service.CancelTransaction(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		switch TransactionState(tx.State) {
+		case TransactionStateCreated:
+			result.State = int(TransactionStateCanceled)
+		case TransactionStateCompleted:
+			result.State = int(TransactionStateCanceledAfterComplete)
+		}
+
+		tx.CancelTime = result.CancelTime
+		tx.State = result.State
+		tx.Reason = params.Reason
+		if err := store.Save(ctx, tx); err != nil {
+			return nil, fmt.Errorf("transaction store save error: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// transactionStore returns the handler Store, creating the default in-memory store if it is not set.
+func (h *MerchantHandler) transactionStore() TransactionStore {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.Store == nil {
+		h.Store = NewMemoryTransactionStore(defaultMaxMerchantTransactions)
+	}
+	return h.Store
+}
+
+// lockTransaction locks the PayMe transaction id, so requests for the same transaction
+// run one at a time while requests for different transactions run concurrently.
+// Returns the unlock function.
+func (h *MerchantHandler) lockTransaction(id string) func() {
+	h.mu.Lock()
+	if h.locks == nil {
+		h.locks = make(map[string]*transactionLock)
+	}
+	lock, ok := h.locks[id]
+	if !ok {
+		lock = &transactionLock{}
+		h.locks[id] = lock
+	}
+	lock.refs++
+	h.mu.Unlock()
+
+	lock.mu.Lock()
+
+	return func() {
+		lock.mu.Unlock()
+
+		h.mu.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(h.locks, id)
+		}
+		h.mu.Unlock()
+	}
+}
+
+// getStatement calls the service GetStatement method for the time window.
+// Returns GetStatementResult with transactions list or an error.
+func (h *MerchantHandler) getStatement(ctx context.Context, params GetStatementParams) (*GetStatementResult, error) {
+	if params.From > params.To {
+		return nil, ErrInvalidRequest
+	}
+
+	entries, err := h.Service.GetStatement(ctx, params.From, params.To)
+	if err != nil {
+		return nil, err
+	}
+
+	// PayMe expects an empty list instead of null
+	if entries == nil {
+		entries = []StatementEntry{}
+	}
+
+	return &GetStatementResult{Transactions: entries}, nil
+}
+
+// isAuthorized verifies the X-Auth or Authorization header with VerifyAuthHeader.
+func (h *MerchantHandler) isAuthorized(r *http.Request) bool {
+	return VerifyAuthHeader(r.Header.Get("X-Auth"), h.MerchantKey) || VerifyAuthHeader(r.Header.Get("Authorization"), h.MerchantKey)
+}
+
+// merchantLogin is the login PayMe uses to authenticate Merchant API callbacks.
+const merchantLogin = "Paycom"
+
+// VerifyAuthHeader checks if the callback auth header contains Paycom:<merchant key>.
+// Both plain and base64 encoded forms are accepted, with or without the "Basic " prefix.
+// Credentials are compared in constant time to avoid timing attacks.
+// Returns true if the header is valid, false otherwise.
+func VerifyAuthHeader(header, merchantKey string) bool {
+	if header == "" || merchantKey == "" {
+		return false
+	}
+
+	expected := []byte(merchantLogin + ":" + merchantKey)
+
+	header = strings.TrimSpace(header)
+	if len(header) > 6 && strings.EqualFold(header[:6], "Basic ") {
+		header = strings.TrimSpace(header[6:])
+	}
+
+	if subtle.ConstantTimeCompare([]byte(header), expected) == 1 {
+		return true
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(header)
+	if err != nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(decoded, expected) == 1
+}
+
+// writeResponse writes the JSON-RPC reply envelope.
+// PayMe expects HTTP 200 for both results and errors.
+func (h *MerchantHandler) writeResponse(w http.ResponseWriter, response merchantResponse) {
+	response.Jsonrpc = "2.0"
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil && h.Logger != nil {
+		h.Logger.Printf("PayMe merchant response write error - %v", err)
+	}
+}
+
+// merchantErrorCodes maps sentinel errors to Merchant API error codes.
+// Subscribe API codes returned by GetErrorCode differ from them, e.g. -31611 for ErrInvalidAmount.
+var merchantErrorCodes = []struct {
+	err  error
+	code int
+}{
+	{ErrInvalidAmount, MerchantInvalidAmountCode},
+	{ErrTransactionNotFound, MerchantTransactionNotFoundCode},
+	{ErrUnableToCancel, UnableToCancelErrorCode},
+	{ErrUnableToPerform, UnableToPerformErrorCode},
+	{ErrPermissionDenied, PermissionDeniedCode},
+	{ErrParseError, ParseErrorCode},
+	{ErrMethodNotFound, MethodNotFoundCode},
+	{ErrInvalidRequest, InvalidRequestCode},
+	{ErrInvalidParams, InvalidRequestCode},
+}
+
+// merchantErrorMessages contains localized messages of Merchant API error codes.
+var merchantErrorMessages = map[int]LocalizedMessage{
+	MerchantInvalidAmountCode: {
+		Ru: "Неверная сумма",
+		Uz: "Noto'g'ri summa",
+		En: "Invalid amount",
+	},
+	MerchantTransactionNotFoundCode: {
+		Ru: "Транзакция не найдена",
+		Uz: "Tranzaksiya topilmadi",
+		En: "Transaction not found",
+	},
+	UnableToCancelErrorCode: {
+		Ru: "Невозможно отменить транзакцию",
+		Uz: "Tranzaksiyani bekor qilib bo'lmaydi",
+		En: "Unable to cancel transaction",
+	},
+	UnableToPerformErrorCode: {
+		Ru: "Невозможно выполнить операцию",
+		Uz: "Amalni bajarib bo'lmaydi",
+		En: "Unable to perform operation",
+	},
+	PermissionDeniedCode: {
+		Ru: "Недостаточно привилегий для выполнения метода",
+		Uz: "Usulni bajarish uchun huquqlar yetarli emas",
+		En: "Insufficient privileges to perform the method",
+	},
+	ParseErrorCode: {
+		Ru: "Ошибка парсинга JSON",
+		Uz: "JSON tahlil qilishda xatolik",
+		En: "JSON parse error",
+	},
+	MethodNotFoundCode: {
+		Ru: "Метод не найден",
+		Uz: "Usul topilmadi",
+		En: "Method not found",
+	},
+	InvalidRequestCode: {
+		Ru: "Неверный запрос",
+		Uz: "Noto'g'ri so'rov",
+		En: "Invalid request",
+	},
+	SystemErrorCode: {
+		Ru: "Системная ошибка",
+		Uz: "Tizim xatoligi",
+		En: "System error",
+	},
+}
+
+// accountErrorMessage is the localized message of account errors in range -31099..-31050.
+var accountErrorMessage = LocalizedMessage{
+	Ru: "Неверные данные аккаунта",
+	Uz: "Hisob ma'lumotlari noto'g'ri",
+	En: "Invalid account",
+}
+
+// newMerchantError creates a Merchant API error with the localized message of the code.
+// Returns a pointer to MerchantError.
+func newMerchantError(code int) *MerchantError {
+	message, ok := merchantErrorMessages[code]
+	if !ok && code >= AccountErrorCodeMin && code <= AccountErrorCodeMax {
+		message = accountErrorMessage
+	}
+	return &MerchantError{Code: code, Message: message}
+}
+
+// toMerchantError converts the service error to Merchant API error reply.
+// *MerchantError is used as is, *Error keeps its code with the message in every language,
+// account errors and known sentinels are mapped to Merchant API codes,
+// and any other error is reported as a system error.
+func (h *MerchantHandler) toMerchantError(err error) *MerchantError {
+	var merchantErr *MerchantError
+	if errors.As(err, &merchantErr) {
+		return merchantErr
+	}
+
+	var paymeErr *Error
+	if errors.As(err, &paymeErr) {
+		reply := newMerchantError(paymeErr.Code)
+		if paymeErr.Message != "" {
+			reply.Message = LocalizedMessage{Ru: paymeErr.Message, Uz: paymeErr.Message, En: paymeErr.Message}
+		}
+		reply.Data = paymeErr.Data
+		return reply
+	}
+
+	for accountErr, code := range h.AccountErrors {
+		if errors.Is(err, accountErr) && code >= AccountErrorCodeMin && code <= AccountErrorCodeMax {
+			return newMerchantError(code)
+		}
+	}
+
+	for _, mapping := range merchantErrorCodes {
+		if errors.Is(err, mapping.err) {
+			return newMerchantError(mapping.code)
+		}
+	}
+
+	return newMerchantError(SystemErrorCode)
+}
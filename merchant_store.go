@@ -0,0 +1,130 @@
+package payment
+
+import (
+	"context"
+	"sync"
+)
+
+// MerchantTransaction contains the transaction state known to MerchantHandler.
+// It is used to answer repeated Create, Perform and Cancel requests without calling the service again.
+type MerchantTransaction struct {
+	// PayMe transaction id
+	ID string `json:"id"`
+	// merchant transaction id
+	Transaction string `json:"transaction"`
+	CreateTime  int64  `json:"create_time"`
+	PerformTime int64  `json:"perform_time"`
+	CancelTime  int64  `json:"cancel_time"`
+	State       int    `json:"state"`
+	Reason      int    `json:"reason"`
+}
+
+// TransactionStore stores Merchant API transactions by PayMe transaction id.
+// Implementations must be safe for concurrent use. Use a persistent store shared
+// by all instances, e.g. the merchant database, when the handler runs on several
+// replicas or must survive restarts.
+type TransactionStore interface {
+	// Get returns the transaction by PayMe transaction id.
+	// Returns the transaction and false if the transaction is unknown.
+	Get(ctx context.Context, id string) (*MerchantTransaction, bool, error)
+	// Save stores the transaction by its PayMe transaction id.
+	Save(ctx context.Context, tx *MerchantTransaction) error
+	// SaveIfAbsent atomically stores the transaction only if its PayMe transaction id is unknown,
+	// e.g. with INSERT ... ON CONFLICT DO NOTHING.
+	// Returns the stored transaction and true, or the existing transaction and false.
+	SaveIfAbsent(ctx context.Context, tx *MerchantTransaction) (*MerchantTransaction, bool, error)
+}
+
+// MemoryTransactionStore is the default in-memory TransactionStore.
+// It keeps at most maxEntries transactions and evicts the least recently saved one when full.
+// Transactions live only in this process, so they are lost on restart and not shared
+// between replicas, and a repeated request for an evicted or lost transaction reaches the service again.
+type MemoryTransactionStore struct {
+	mu           sync.Mutex
+	maxEntries   int
+	seq          uint64
+	transactions map[string]*memoryTransaction
+}
+
+// memoryTransaction contains a stored transaction with its save sequence number.
+type memoryTransaction struct {
+	tx  MerchantTransaction
+	seq uint64
+}
+
+// NewMemoryTransactionStore creates a new in-memory transaction store.
+// Returns a pointer to MemoryTransactionStore with the provided size limit.
+func NewMemoryTransactionStore(maxEntries int) *MemoryTransactionStore {
+	return &MemoryTransactionStore{
+		maxEntries:   maxEntries,
+		transactions: make(map[string]*memoryTransaction),
+	}
+}
+
+// Get returns a copy of the stored transaction.
+func (m *MemoryTransactionStore) Get(_ context.Context, id string) (*MerchantTransaction, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.transactions[id]
+	if !ok {
+		return nil, false, nil
+	}
+
+	tx := entry.tx
+	return &tx, true, nil
+}
+
+// Save stores a copy of the transaction, evicting the oldest one if the store is full.
+func (m *MemoryTransactionStore) Save(_ context.Context, tx *MerchantTransaction) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.transactions[tx.ID]; !ok && m.maxEntries > 0 && len(m.transactions) >= m.maxEntries {
+		m.evict()
+	}
+
+	m.seq++
+	m.transactions[tx.ID] = &memoryTransaction{tx: *tx, seq: m.seq}
+
+	return nil
+}
+
+// SaveIfAbsent stores a copy of the transaction if its id is unknown.
+// Returns a copy of the stored or existing transaction.
+func (m *MemoryTransactionStore) SaveIfAbsent(_ context.Context, tx *MerchantTransaction) (*MerchantTransaction, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if entry, ok := m.transactions[tx.ID]; ok {
+		existing := entry.tx
+		return &existing, false, nil
+	}
+
+	if m.maxEntries > 0 && len(m.transactions) >= m.maxEntries {
+		m.evict()
+	}
+
+	m.seq++
+	m.transactions[tx.ID] = &memoryTransaction{tx: *tx, seq: m.seq}
+
+	saved := *tx
+	return &saved, true, nil
+}
+
+// evict removes the least recently saved transaction.
+func (m *MemoryTransactionStore) evict() {
+	var (
+		oldestID  string
+		oldestSeq uint64
+	)
+
+	for id, entry := range m.transactions {
+		if oldestID == "" || entry.seq < oldestSeq {
+			oldestID = id
+			oldestSeq = entry.seq
+		}
+	}
+
+	delete(m.transactions, oldestID)
+}
@@ -0,0 +1,74 @@
+package payment
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMemoryTransactionStoreReturnsCopies(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryTransactionStore(10)
+
+	tx := &MerchantTransaction{ID: "pay-1", Transaction: "tx-1", State: 1}
+	if err := store.Save(ctx, tx); err != nil {
+		t.Fatalf("save error: %v", err)
+	}
+	tx.State = 2
+
+	got, ok, err := store.Get(ctx, "pay-1")
+	if err != nil || !ok {
+		t.Fatalf("get = %v, %v, want stored transaction", ok, err)
+	}
+	if got.State != 1 {
+		t.Errorf("state = %d, want 1, store must not alias saved value", got.State)
+	}
+
+	got.State = -1
+	again, _, _ := store.Get(ctx, "pay-1")
+	if again.State != 1 {
+		t.Errorf("state = %d, want 1, store must not alias returned value", again.State)
+	}
+}
+
+func TestMemoryTransactionStoreEvictsOldest(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryTransactionStore(2)
+
+	for _, id := range []string{"pay-1", "pay-2", "pay-3"} {
+		if err := store.Save(ctx, &MerchantTransaction{ID: id}); err != nil {
+			t.Fatalf("save error: %v", err)
+		}
+	}
+
+	if _, ok, _ := store.Get(ctx, "pay-1"); ok {
+		t.Error("pay-1 is not evicted")
+	}
+	for _, id := range []string{"pay-2", "pay-3"} {
+		if _, ok, _ := store.Get(ctx, id); !ok {
+			t.Errorf("%s is evicted", id)
+		}
+	}
+
+	// Updating a stored transaction never evicts
+	if err := store.Save(ctx, &MerchantTransaction{ID: "pay-2", State: 2}); err != nil {
+		t.Fatalf("save error: %v", err)
+	}
+	if _, ok, _ := store.Get(ctx, "pay-3"); !ok {
+		t.Error("pay-3 is evicted by update")
+	}
+}
+
+func TestMemoryTransactionStoreSaveIfAbsentKeepsFirst(t *testing.T) {
+	store := NewMemoryTransactionStore(10)
+	ctx := context.Background()
+
+	stored, saved, err := store.SaveIfAbsent(ctx, &MerchantTransaction{ID: "pay-1", Transaction: "tx-1", State: 1})
+	if err != nil || !saved || stored.Transaction != "tx-1" {
+		t.Fatalf("first SaveIfAbsent = %+v, %v, %v, want saved tx-1", stored, saved, err)
+	}
+
+	stored, saved, err = store.SaveIfAbsent(ctx, &MerchantTransaction{ID: "pay-1", Transaction: "tx-2", State: 1})
+	if err != nil || saved || stored.Transaction != "tx-1" {
+		t.Errorf("second SaveIfAbsent = %+v, %v, %v, want existing tx-1", stored, saved, err)
+	}
+}
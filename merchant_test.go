@@ -0,0 +1,375 @@
+package payment
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stubMerchantService is a MerchantService with overridable methods.
+type stubMerchantService struct {
+	checkPerform func(ctx context.Context, params CheckPerformTransactionParams) (*CheckPerformTransactionResult, error)
+	create       func(ctx context.Context, params CreateTransactionParams) (*CreateTransactionResult, error)
+	perform      func(ctx context.Context, params PerformTransactionParams) (*PerformTransactionResult, error)
+	cancel       func(ctx context.Context, params CancelTransactionParams) (*CancelTransactionResult, error)
+}
+
+func (s *stubMerchantService) CheckPerformTransaction(ctx context.Context, params CheckPerformTransactionParams) (*CheckPerformTransactionResult, error) {
+	if s.checkPerform == nil {
+		return &CheckPerformTransactionResult{Allow: true}, nil
+	}
+	return s.checkPerform(ctx, params)
+}
+
+func (s *stubMerchantService) CreateTransaction(ctx context.Context, params CreateTransactionParams) (*CreateTransactionResult, error) {
+	return s.create(ctx, params)
+}
+
+func (s *stubMerchantService) PerformTransaction(ctx context.Context, params PerformTransactionParams) (*PerformTransactionResult, error) {
+	return s.perform(ctx, params)
+}
+
+func (s *stubMerchantService) CancelTransaction(ctx context.Context, params CancelTransactionParams) (*CancelTransactionResult, error) {
+	return s.cancel(ctx, params)
+}
+
+func (s *stubMerchantService) CheckTransaction(ctx context.Context, params CheckTransactionParams) (*CheckTransactionResult, error) {
+	return &CheckTransactionResult{}, nil
+}
+
+func (s *stubMerchantService) GetStatement(ctx context.Context, from, to int64) ([]StatementEntry, error) {
+	return nil, nil
+}
+
+// serveMerchant sends the body to the handler with valid credentials.
+func serveMerchant(t *testing.T, h *MerchantHandler, body string) map[string]json.RawMessage {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/payme", strings.NewReader(body))
+	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("Paycom:secret")))
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(rec, req)
+
+	var reply map[string]json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &reply); err != nil {
+		t.Fatalf("reply unmarshal error: %v, body - %s", err, rec.Body.String())
+	}
+	return reply
+}
+
+func TestMerchantHandlerEchoesRawID(t *testing.T) {
+	h := NewMerchantHandler("secret", &stubMerchantService{})
+
+	tests := []struct {
+		name string
+		id   string
+	}{
+		{"number", `12345`},
+		{"large number", `98765432109876543`},
+		{"string", `"abc-1"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"id":` + tt.id + `,"method":"CheckPerformTransaction","params":{"amount":100,"account":{"id":"1"}}}`
+			reply := serveMerchant(t, h, body)
+
+			if got := string(reply["id"]); got != tt.id {
+				t.Errorf("id = %s, want %s", got, tt.id)
+			}
+		})
+	}
+}
+
+func TestMerchantHandlerRejectsOversizedBody(t *testing.T) {
+	h := NewMerchantHandler("secret", &stubMerchantService{})
+	h.MaxRequestBytes = 64
+
+	body := `{"id":1,"method":"CheckPerformTransaction","params":{"account":{"id":"` + strings.Repeat("x", 128) + `"}}}`
+	reply := serveMerchant(t, h, body)
+
+	var replyErr MerchantError
+	if err := json.Unmarshal(reply["error"], &replyErr); err != nil {
+		t.Fatalf("error unmarshal error: %v", err)
+	}
+	if replyErr.Code != ParseErrorCode {
+		t.Errorf("code = %d, want %d", replyErr.Code, ParseErrorCode)
+	}
+}
+
+func TestMerchantHandlerRunsServiceConcurrently(t *testing.T) {
+	var running, maxRunning int32
+	service := &stubMerchantService{
+		create: func(ctx context.Context, params CreateTransactionParams) (*CreateTransactionResult, error) {
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+					break
+				}
+			}
+			time.Sleep(50 * time.Millisecond)
+			return &CreateTransactionResult{CreateTime: params.Time, Transaction: "tx-" + params.ID, State: 1}, nil
+		},
+	}
+	h := NewMerchantHandler("secret", service)
+
+	done := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		go func(i int) {
+			defer func() { done <- struct{}{} }()
+			body := `{"id":1,"method":"CreateTransaction","params":{"id":"pay-` + string(rune('a'+i)) + `","time":1,"amount":100,"account":{"id":"1"}}}`
+			req := httptest.NewRequest(http.MethodPost, "/payme", strings.NewReader(body))
+			req.Header.Set("X-Auth", "Paycom:secret")
+			h.ServeHTTP(httptest.NewRecorder(), req)
+		}(i)
+	}
+	<-done
+	<-done
+
+	if maxRunning != 2 {
+		t.Errorf("max concurrent service calls = %d, want 2", maxRunning)
+	}
+}
+
+func TestMerchantHandlerCreateTransactionIsIdempotentAcrossHandlers(t *testing.T) {
+	var calls int32
+	service := &stubMerchantService{
+		create: func(ctx context.Context, params CreateTransactionParams) (*CreateTransactionResult, error) {
+			atomic.AddInt32(&calls, 1)
+			return &CreateTransactionResult{CreateTime: params.Time, Transaction: "tx-1", State: 1}, nil
+		},
+	}
+
+	// Two replicas sharing a store
+	store := NewMemoryTransactionStore(10)
+	first := NewMerchantHandler("secret", service)
+	first.Store = store
+	second := NewMerchantHandler("secret", service)
+	second.Store = store
+
+	body := `{"id":7,"method":"CreateTransaction","params":{"id":"pay-1","time":1700000000000,"amount":100,"account":{"id":"1"}}}`
+	serveMerchant(t, first, body)
+	reply := serveMerchant(t, second, body)
+
+	if calls != 1 {
+		t.Errorf("service calls = %d, want 1", calls)
+	}
+
+	var result CreateTransactionResult
+	if err := json.Unmarshal(reply["result"], &result); err != nil {
+		t.Fatalf("result unmarshal error: %v", err)
+	}
+	if result.Transaction != "tx-1" || result.CreateTime != 1700000000000 || result.State != 1 {
+		t.Errorf("result = %+v, want stored transaction", result)
+	}
+}
+
+func TestMerchantHandlerCreateTransactionIsIdempotentUnderConcurrency(t *testing.T) {
+	var calls int32
+	service := &stubMerchantService{
+		create: func(ctx context.Context, params CreateTransactionParams) (*CreateTransactionResult, error) {
+			n := atomic.AddInt32(&calls, 1)
+			time.Sleep(10 * time.Millisecond)
+			return &CreateTransactionResult{CreateTime: params.Time, Transaction: fmt.Sprintf("tx-%d", n), State: 1}, nil
+		},
+	}
+	h := NewMerchantHandler("secret", service)
+
+	const requests = 8
+	body := `{"id":7,"method":"CreateTransaction","params":{"id":"pay-1","time":1700000000000,"amount":100,"account":{"id":"1"}}}`
+	results := make(chan CreateTransactionResult, requests)
+	for i := 0; i < requests; i++ {
+		go func() {
+			req := httptest.NewRequest(http.MethodPost, "/payme", strings.NewReader(body))
+			req.Header.Set("X-Auth", "Paycom:secret")
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			var reply struct {
+				Result CreateTransactionResult `json:"result"`
+			}
+			_ = json.Unmarshal(rec.Body.Bytes(), &reply)
+			results <- reply.Result
+		}()
+	}
+
+	for i := 0; i < requests; i++ {
+		if result := <-results; result.Transaction != "tx-1" {
+			t.Errorf("result = %+v, want tx-1", result)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("service calls = %d, want 1", calls)
+	}
+	if len(h.locks) != 0 {
+		t.Errorf("transaction locks = %d, want 0", len(h.locks))
+	}
+}
+
+// failingTransactionStore is a TransactionStore failing every call.
+type failingTransactionStore struct{}
+
+func (failingTransactionStore) Get(context.Context, string) (*MerchantTransaction, bool, error) {
+	return nil, false, errors.New("database is down")
+}
+
+func (failingTransactionStore) Save(context.Context, *MerchantTransaction) error {
+	return errors.New("database is down")
+}
+
+func (failingTransactionStore) SaveIfAbsent(context.Context, *MerchantTransaction) (*MerchantTransaction, bool, error) {
+	return nil, false, errors.New("database is down")
+}
+
+func TestMerchantHandlerReportsStoreErrorAsSystemError(t *testing.T) {
+	h := NewMerchantHandler("secret", &stubMerchantService{})
+	h.Store = failingTransactionStore{}
+
+	reply := serveMerchant(t, h, `{"id":1,"method":"PerformTransaction","params":{"id":"pay-1"}}`)
+
+	var replyErr MerchantError
+	if err := json.Unmarshal(reply["error"], &replyErr); err != nil {
+		t.Fatalf("error unmarshal error: %v", err)
+	}
+	if replyErr.Code != SystemErrorCode {
+		t.Errorf("code = %d, want %d", replyErr.Code, SystemErrorCode)
+	}
+}
+
+func TestMerchantHandlerMapsErrorsToMerchantCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code int
+		en   string
+	}{
+		{"invalid amount", fmt.Errorf("order total differs: %w", ErrInvalidAmount), MerchantInvalidAmountCode, "Invalid amount"},
+		{"transaction not found", ErrTransactionNotFound, MerchantTransactionNotFoundCode, "Transaction not found"},
+		{"unable to perform", ErrUnableToPerform, UnableToPerformErrorCode, "Unable to perform operation"},
+		{"account error", ErrOrderHasTransaction, AccountErrorCodeMax, "Invalid account"},
+		{"payme error", &Error{Code: -31060, Message: "Order is canceled", Data: "order_id"}, -31060, "Order is canceled"},
+		{"localized error", &MerchantError{Code: -31051, Message: LocalizedMessage{Ru: "Заказ не найден", Uz: "Buyurtma topilmadi", En: "Order not found"}}, -31051, "Order not found"},
+		{"unknown", errors.New("database is down"), SystemErrorCode, "System error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewMerchantHandler("secret", &stubMerchantService{
+				checkPerform: func(ctx context.Context, params CheckPerformTransactionParams) (*CheckPerformTransactionResult, error) {
+					return nil, tt.err
+				},
+			})
+
+			reply := serveMerchant(t, h, `{"id":1,"method":"CheckPerformTransaction","params":{"amount":100,"account":{"id":"1"}}}`)
+
+			var replyErr MerchantError
+			if err := json.Unmarshal(reply["error"], &replyErr); err != nil {
+				t.Fatalf("error unmarshal error: %v", err)
+			}
+			if replyErr.Code != tt.code {
+				t.Errorf("code = %d, want %d", replyErr.Code, tt.code)
+			}
+			if replyErr.Message.En != tt.en || replyErr.Message.Ru == "" || replyErr.Message.Uz == "" {
+				t.Errorf("message = %+v, want localized message with en %q", replyErr.Message, tt.en)
+			}
+		})
+	}
+}
+
+func TestMerchantHandlerChecksAuthBeforeBody(t *testing.T) {
+	h := NewMerchantHandler("secret", &stubMerchantService{})
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+	}{
+		{"no credentials", "", ""},
+		{"raw key in X-Auth", "X-Auth", "secret"},
+		{"wrong key", "Authorization", "Basic " + base64.StdEncoding.EncodeToString([]byte("Paycom:wrong"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/payme", strings.NewReader(`{"id":1,"method":`))
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			var reply struct {
+				Error *MerchantError `json:"error"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &reply); err != nil {
+				t.Fatalf("reply unmarshal error: %v", err)
+			}
+			if reply.Error == nil || reply.Error.Code != PermissionDeniedCode {
+				t.Errorf("error = %+v, want code %d", reply.Error, PermissionDeniedCode)
+			}
+		})
+	}
+}
+
+func TestVerifyAuthHeader(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString([]byte("Paycom:secret"))
+
+	tests := []struct {
+		name   string
+		header string
+		key    string
+		want   bool
+	}{
+		{"plain", "Paycom:secret", "secret", true},
+		{"plain with basic prefix", "Basic Paycom:secret", "secret", true},
+		{"base64", encoded, "secret", true},
+		{"base64 with basic prefix", "Basic " + encoded, "secret", true},
+		{"lowercase basic prefix", "basic " + encoded, "secret", true},
+		{"surrounding spaces", "  Basic " + encoded + " ", "secret", true},
+		{"wrong key", "Paycom:other", "secret", false},
+		{"wrong base64 key", base64.StdEncoding.EncodeToString([]byte("Paycom:other")), "secret", false},
+		{"wrong login", base64.StdEncoding.EncodeToString([]byte("Admin:secret")), "secret", false},
+		{"key only", "secret", "secret", false},
+		{"invalid base64", "Basic !!!", "secret", false},
+		{"empty header", "", "secret", false},
+		{"empty key", "Paycom:", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyAuthHeader(tt.header, tt.key); got != tt.want {
+				t.Errorf("VerifyAuthHeader(%q) = %t, want %t", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMerchantHandlerRejectsInvalidAuth(t *testing.T) {
+	h := NewMerchantHandler("secret", &stubMerchantService{})
+
+	req := httptest.NewRequest(http.MethodPost, "/payme", strings.NewReader(`{"id":1,"method":"CheckPerformTransaction","params":{}}`))
+	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("Paycom:wrong")))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var reply struct {
+		Error *MerchantError `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &reply); err != nil {
+		t.Fatalf("reply unmarshal error: %v", err)
+	}
+	if reply.Error == nil || reply.Error.Code != PermissionDeniedCode {
+		t.Errorf("error = %+v, want code %d", reply.Error, PermissionDeniedCode)
+	}
+}
@@ -0,0 +1,133 @@
+package payment
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MetricsCollector observes PayMe API calls.
+// ObserveCall is called once per API call or batch with the total duration including retries.
+type MetricsCollector interface {
+	ObserveCall(method string, duration time.Duration, err error)
+}
+
+// DefaultBuckets are the default latency histogram buckets in seconds.
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// PrometheusCollector is a MetricsCollector exposing a latency histogram
+// in Prometheus text format. The histogram is labeled by method and code,
+// where code is "ok", the PayMe error code, or "error" for other failures.
+// It implements http.Handler, so it can be mounted on the metrics endpoint.
+type PrometheusCollector struct {
+	mu         sync.Mutex
+	name       string
+	buckets    []float64
+	histograms map[histogramKey]*histogram
+}
+
+// histogramKey contains label values of a histogram series.
+type histogramKey struct {
+	method string
+	code   string
+}
+
+// histogram contains cumulative bucket counts, sum and count of a series.
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewPrometheusCollector creates a new collector with metric name
+// "<namespace>_request_duration_seconds" and the provided buckets.
+// DefaultBuckets are used if buckets is empty.
+// Returns a pointer to PrometheusCollector.
+func NewPrometheusCollector(namespace string, buckets []float64) *PrometheusCollector {
+	if namespace == "" {
+		namespace = "payme"
+	}
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+
+	return &PrometheusCollector{
+		name:       namespace + "_request_duration_seconds",
+		buckets:    sorted,
+		histograms: make(map[histogramKey]*histogram),
+	}
+}
+
+// ObserveCall adds the call duration to the histogram of method and error code.
+func (p *PrometheusCollector) ObserveCall(method string, duration time.Duration, err error) {
+	key := histogramKey{method: method, code: metricsCode(err)}
+	seconds := duration.Seconds()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	h, ok := p.histograms[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(p.buckets))}
+		p.histograms[key] = h
+	}
+
+	for i, bound := range p.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// ServeHTTP writes the histogram in Prometheus text exposition format.
+func (p *PrometheusCollector) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	keys := make([]histogramKey, 0, len(p.histograms))
+	for key := range p.histograms {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].code < keys[j].code
+	})
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	fmt.Fprintf(w, "# HELP %s Duration of PayMe API calls.\n", p.name)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", p.name)
+
+	for _, key := range keys {
+		h := p.histograms[key]
+		labels := fmt.Sprintf("method=%q,code=%q", key.method, key.code)
+
+		for i, bound := range p.buckets {
+			fmt.Fprintf(w, "%s_bucket{%s,le=%q} %d\n", p.name, labels, strconv.FormatFloat(bound, 'g', -1, 64), h.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", p.name, labels, h.count)
+		fmt.Fprintf(w, "%s_sum{%s} %s\n", p.name, labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(w, "%s_count{%s} %d\n", p.name, labels, h.count)
+	}
+}
+
+// metricsCode returns the code label value for the call error.
+func metricsCode(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	if code := GetErrorCode(err); code != 0 {
+		return strconv.Itoa(code)
+	}
+	return "error"
+}
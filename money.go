@@ -0,0 +1,83 @@
+package payment
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Money represents a monetary amount in tiyin (smallest currency unit).
+// It never passes amounts through float math, so there are no rounding errors.
+type Money int64
+
+// MoneyFromSom parses a decimal som amount like "19.99" into Money.
+// At most two fraction digits are allowed.
+// Returns Money in tiyin or ErrInvalidAmount if the amount cannot be parsed.
+func MoneyFromSom(som string) (Money, error) {
+	som = strings.TrimSpace(som)
+
+	negative := strings.HasPrefix(som, "-")
+	if negative {
+		som = som[1:]
+	}
+
+	whole, fraction, hasFraction := strings.Cut(som, ".")
+	if whole == "" || (hasFraction && (fraction == "" || len(fraction) > 2)) {
+		return 0, ErrInvalidAmount
+	}
+
+	for _, part := range []string{whole, fraction} {
+		for i := 0; i < len(part); i++ {
+			if part[i] < '0' || part[i] > '9' {
+				return 0, ErrInvalidAmount
+			}
+		}
+	}
+
+	// pad fraction to two digits: "19.9" -> 1990 tiyin
+	fraction += strings.Repeat("0", 2-len(fraction))
+
+	tiyin, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidAmount
+	}
+
+	if negative {
+		tiyin = -tiyin
+	}
+
+	return Money(tiyin), nil
+}
+
+// MoneyFromTiyin creates Money from an amount in tiyin.
+func MoneyFromTiyin(tiyin int64) Money {
+	return Money(tiyin)
+}
+
+// Tiyin returns the amount in tiyin as int64.
+func (m Money) Tiyin() int64 {
+	return int64(m)
+}
+
+// Add returns the sum of two amounts.
+func (m Money) Add(other Money) Money {
+	return m + other
+}
+
+// Sub returns the difference of two amounts.
+func (m Money) Sub(other Money) Money {
+	return m - other
+}
+
+// String formats the amount in som with two fraction digits like "19.99".
+func (m Money) String() string {
+	tiyin := int64(m)
+
+	sign := ""
+	if tiyin < 0 {
+		sign = "-"
+		tiyin = -tiyin
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, tiyin/100, tiyin%100)
+}
@@ -0,0 +1,112 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMoneyFromSom(t *testing.T) {
+	tests := []struct {
+		name    string
+		som     string
+		want    Money
+		wantErr bool
+	}{
+		{name: "two fraction digits", som: "19.99", want: 1999},
+		{name: "one fraction digit", som: "19.9", want: 1990},
+		{name: "whole", som: "20", want: 2000},
+		{name: "surrounding spaces", som: " 0.01 ", want: 1},
+		{name: "negative", som: "-5.25", want: -525},
+		{name: "negative zero", som: "-0", want: 0},
+		{name: "largest amount", som: "92233720368547758.07", want: 9223372036854775807},
+		{name: "three fraction digits", som: "19.999", wantErr: true},
+		{name: "many fraction digits", som: "0.00001", wantErr: true},
+		{name: "empty", som: "", wantErr: true},
+		{name: "spaces only", som: "   ", wantErr: true},
+		{name: "sign only", som: "-", wantErr: true},
+		{name: "missing whole", som: ".5", wantErr: true},
+		{name: "missing fraction", som: "5.", wantErr: true},
+		{name: "plus sign", som: "+5", wantErr: true},
+		{name: "double negative", som: "--5", wantErr: true},
+		{name: "comma separator", som: "19,99", wantErr: true},
+		{name: "exponent", som: "1e3", wantErr: true},
+		{name: "overflow", som: "92233720368547758.08", wantErr: true},
+		{name: "overflow whole", som: "100000000000000000000", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MoneyFromSom(tt.som)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidAmount) {
+					t.Errorf("MoneyFromSom(%q) = %d, %v, want ErrInvalidAmount", tt.som, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("MoneyFromSom(%q) = %d, %v, want %d", tt.som, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{money: 1999, want: "19.99"},
+		{money: 5, want: "0.05"},
+		{money: -525, want: "-5.25"},
+		{money: MoneyFromTiyin(1990).Add(10).Sub(1), want: "19.99"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.money.String(); got != tt.want {
+			t.Errorf("Money(%d).String() = %q, want %q", int64(tt.money), got, tt.want)
+		}
+	}
+}
+
+func TestCreateReceiptWithMoney(t *testing.T) {
+	var amounts []float64
+	server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+		amounts = append(amounts, call.Params["amount"].(float64))
+		return receiptResult(Receipt{ID: "receipt-1"}), nil
+	})
+	client := newTestClient(t, server.URL)
+	account := map[string]interface{}{"id": "42"}
+
+	money, err := MoneyFromSom("19.99")
+	if err != nil {
+		t.Fatalf("MoneyFromSom error: %v", err)
+	}
+	if _, err := client.CreateReceiptWithMoney(context.Background(), money, account, "order 42", nil); err != nil {
+		t.Fatalf("CreateReceiptWithMoney error: %v", err)
+	}
+	if len(amounts) != 1 || amounts[0] != 1999 {
+		t.Errorf("sent amounts = %v, want [1999]", amounts)
+	}
+
+	var calls int32
+	rejecting := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+		atomic.AddInt32(&calls, 1)
+		return receiptResult(Receipt{ID: "receipt-1"}), nil
+	})
+	client = newTestClient(t, rejecting.URL)
+
+	for _, som := range []string{"-5.25", "0", "0.00", "10000000000.00"} {
+		money, err := MoneyFromSom(som)
+		if err != nil {
+			t.Fatalf("MoneyFromSom(%q) error: %v", som, err)
+		}
+		if _, err := client.CreateReceiptWithMoney(context.Background(), money, account, "order 42", nil); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("CreateReceiptWithMoney(%s) error = %v, want ErrInvalidAmount", som, err)
+		}
+	}
+	if calls != 0 {
+		t.Errorf("server calls = %d, want 0 for invalid amounts", calls)
+	}
+}
@@ -0,0 +1,36 @@
+package payment
+
+import "time"
+
+// RequestOption configures a single PayMe API call.
+type RequestOption func(*requestOptions)
+
+// requestOptions contains per-call settings.
+type requestOptions struct {
+	timeout        time.Duration
+	ignoreNotFound bool
+}
+
+// WithTimeout overrides the client timeout for a single call,
+// e.g. a longer timeout for receipts.pay than for receipts.check.
+func WithTimeout(timeout time.Duration) RequestOption {
+	return func(o *requestOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithIgnoreNotFound makes RemoveCard treat a "card not found" response as successful removal.
+func WithIgnoreNotFound() RequestOption {
+	return func(o *requestOptions) {
+		o.ignoreNotFound = true
+	}
+}
+
+// applyOptions applies the options to default per-call settings.
+func applyOptions(opts []RequestOption) requestOptions {
+	var o requestOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -0,0 +1,74 @@
+package paymetest
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	payment "payme.kisuke.uz"
+)
+
+func TestFakeClockControlsClientTime(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		ids []string
+		tos []int64
+	)
+	server := newRPCServer(t, func(call rpcCall) interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+
+		ids = append(ids, call.ID)
+		tos = append(tos, int64(call.Params["to"].(float64)))
+		return []interface{}{}
+	})
+
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	client := newTestClient(t, server.URL, func(config *payment.ClientConfig) {
+		config.Clock = clock
+	})
+
+	if got := client.CurrentTimestamp(); got != start.UnixMilli() {
+		t.Errorf("CurrentTimestamp = %d, want %d", got, start.UnixMilli())
+	}
+
+	find := func() {
+		t.Helper()
+		if _, err := client.FindReceiptByOrderID(context.Background(), "42", time.Hour); err == nil {
+			t.Fatal("FindReceiptByOrderID error = nil, want ErrReceiptNotFound")
+		}
+	}
+
+	find()
+	clock.Advance(time.Hour)
+	find()
+	moved := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock.Set(moved)
+	find()
+
+	wantTos := []int64{start.UnixMilli(), start.Add(time.Hour).UnixMilli(), moved.UnixMilli()}
+	for i, want := range wantTos {
+		if tos[i] != want {
+			t.Errorf("request %d to = %d, want %d", i, tos[i], want)
+		}
+	}
+
+	wantIDs := []string{
+		"ReceiptsGetAll:" + strconv.FormatInt(start.UnixNano(), 10) + "-1",
+		"ReceiptsGetAll:" + strconv.FormatInt(start.Add(time.Hour).UnixNano(), 10) + "-2",
+		"ReceiptsGetAll:" + strconv.FormatInt(moved.UnixNano(), 10) + "-3",
+	}
+	for i, want := range wantIDs {
+		if ids[i] != want {
+			t.Errorf("request %d id = %q, want %q", i, ids[i], want)
+		}
+	}
+
+	if got := client.GenerateReceiptID("charge-1"); !strings.HasSuffix(got, strconv.FormatInt(moved.Unix(), 10)) {
+		t.Errorf("GenerateReceiptID = %q, want suffix %d", got, moved.Unix())
+	}
+}
@@ -0,0 +1,61 @@
+package paymetest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	payment "payme.kisuke.uz"
+)
+
+// rpcCall is a JSON-RPC request received by the test PayMe server.
+type rpcCall struct {
+	ID     string                 `json:"id"`
+	Method string                 `json:"method"`
+	Params map[string]interface{} `json:"params"`
+}
+
+// newRPCServer starts a test PayMe server answering every call with the handler result.
+// The response id echoes the request id.
+func newRPCServer(t *testing.T, handler func(call rpcCall) interface{}) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var call rpcCall
+		if err := json.NewDecoder(r.Body).Decode(&call); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(payment.Response{Jsonrpc: "2.0", ID: call.ID, Result: handler(call)})
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// newTestClient creates a client sending requests to the url.
+// The configure functions can change the config before the client is created.
+func newTestClient(t *testing.T, url string, configure ...func(*payment.ClientConfig)) *payment.Client {
+	t.Helper()
+
+	config := payment.ClientConfig{
+		PaymeID:      "5e730e8e0b852a417aa49ceb",
+		PaymeKey:     "test-key",
+		IsTestMode:   true,
+		BaseURL:      url,
+		RetryBackoff: time.Millisecond,
+	}
+	for _, fn := range configure {
+		fn(&config)
+	}
+
+	client, err := payment.NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient error: %v", err)
+	}
+	return client
+}
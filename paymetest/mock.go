@@ -0,0 +1,174 @@
+// Package paymetest provides a mock PayMe client for unit testing merchant code.
+package paymetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	payment "payme.kisuke.uz"
+)
+
+// ErrNoResponseQueued is returned when a method is called without a queued response.
+var ErrNoResponseQueued = errors.New("paymetest: no response queued")
+
+// Call represents a recorded call of MockClient.
+// Args contains all method arguments except the context.
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// response contains a queued method response.
+type response struct {
+	value interface{}
+	err   error
+}
+
+// MockClient is a programmable payment.PaymeClient for unit tests.
+// Responses are queued per method name and returned in order,
+// and every call is recorded with its arguments.
+type MockClient struct {
+	mu        sync.Mutex
+	responses map[string][]response
+	calls     []Call
+}
+
+// MockClient must implement payment.PaymeClient
+var _ payment.PaymeClient = (*MockClient)(nil)
+
+// NewMockClient creates a new mock client without queued responses.
+// Returns a pointer to MockClient.
+func NewMockClient() *MockClient {
+	return &MockClient{
+		responses: make(map[string][]response),
+	}
+}
+
+// Queue adds a response for the method like "CreateReceipt".
+// value must be a pointer to the method response type, e.g. *payment.CreateReceiptResponse.
+func (m *MockClient) Queue(method string, value interface{}, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.responses[method] = append(m.responses[method], response{value: value, err: err})
+}
+
+// Calls returns all recorded calls in order.
+func (m *MockClient) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Call(nil), m.calls...)
+}
+
+// CallsTo returns recorded calls of the method in order.
+func (m *MockClient) CallsTo(method string) []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var calls []Call
+	for _, call := range m.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// record saves the call and pops the next queued response of the method.
+func (m *MockClient) record(method string, args ...interface{}) (interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = append(m.calls, Call{Method: method, Args: args})
+
+	queue := m.responses[method]
+	if len(queue) == 0 {
+		return nil, fmt.Errorf("%w for %s", ErrNoResponseQueued, method)
+	}
+	m.responses[method] = queue[1:]
+
+	return queue[0].value, queue[0].err
+}
+
+// next records the call and returns the queued response as *T.
+func next[T any](m *MockClient, method string, args ...interface{}) (*T, error) {
+	value, err := m.record(method, args...)
+	if value == nil {
+		return nil, err
+	}
+
+	result, ok := value.(*T)
+	if !ok {
+		return nil, fmt.Errorf("paymetest: queued %T for %s, want %T", value, method, result)
+	}
+
+	return result, err
+}
+
+// CreateReceipt records the call and returns the queued *payment.CreateReceiptResponse.
+func (m *MockClient) CreateReceipt(_ context.Context, amount int64, account map[string]interface{}, description string, detail map[string]interface{}, _ ...payment.RequestOption) (*payment.CreateReceiptResponse, error) {
+	return next[payment.CreateReceiptResponse](m, "CreateReceipt", amount, account, description, detail)
+}
+
+// PayReceipt records the call and returns the queued *payment.PayReceiptResponse.
+func (m *MockClient) PayReceipt(_ context.Context, receiptID, token string, _ ...payment.RequestOption) (*payment.PayReceiptResponse, error) {
+	return next[payment.PayReceiptResponse](m, "PayReceipt", receiptID, token)
+}
+
+// SendReceipt records the call and returns the queued *payment.SendReceiptResponse.
+func (m *MockClient) SendReceipt(_ context.Context, receiptID string, _ ...payment.RequestOption) (*payment.SendReceiptResponse, error) {
+	return next[payment.SendReceiptResponse](m, "SendReceipt", receiptID)
+}
+
+// CancelReceipt records the call and returns the queued *payment.CancelReceiptResponse.
+func (m *MockClient) CancelReceipt(_ context.Context, receiptID string, _ ...payment.RequestOption) (*payment.CancelReceiptResponse, error) {
+	return next[payment.CancelReceiptResponse](m, "CancelReceipt", receiptID)
+}
+
+// CheckReceipt records the call and returns the queued *payment.CheckReceiptResponse.
+func (m *MockClient) CheckReceipt(_ context.Context, receiptID string, _ ...payment.RequestOption) (*payment.CheckReceiptResponse, error) {
+	return next[payment.CheckReceiptResponse](m, "CheckReceipt", receiptID)
+}
+
+// GetReceipt records the call and returns the queued *payment.GetReceiptResponse.
+func (m *MockClient) GetReceipt(_ context.Context, receiptID string, _ ...payment.RequestOption) (*payment.GetReceiptResponse, error) {
+	return next[payment.GetReceiptResponse](m, "GetReceipt", receiptID)
+}
+
+// GetAllReceipts records the call and returns the queued *payment.GetAllReceiptsResponse.
+func (m *MockClient) GetAllReceipts(_ context.Context, from, to int64, count int, _ ...payment.RequestOption) (*payment.GetAllReceiptsResponse, error) {
+	return next[payment.GetAllReceiptsResponse](m, "GetAllReceipts", from, to, count)
+}
+
+// SetFiscalData records the call and returns the queued *payment.SetFiscalDataResponse.
+func (m *MockClient) SetFiscalData(_ context.Context, receiptID string, fiscalData map[string]interface{}, _ ...payment.RequestOption) (*payment.SetFiscalDataResponse, error) {
+	return next[payment.SetFiscalDataResponse](m, "SetFiscalData", receiptID, fiscalData)
+}
+
+// CreateCard records the call and returns the queued *payment.CreateCardResponse.
+func (m *MockClient) CreateCard(_ context.Context, cardNumber, expire string, save bool, _ ...payment.RequestOption) (*payment.CreateCardResponse, error) {
+	return next[payment.CreateCardResponse](m, "CreateCard", cardNumber, expire, save)
+}
+
+// GetCardVerifyCode records the call and returns the queued *payment.GetVerifyCodeResponse.
+func (m *MockClient) GetCardVerifyCode(_ context.Context, token string, _ ...payment.RequestOption) (*payment.GetVerifyCodeResponse, error) {
+	return next[payment.GetVerifyCodeResponse](m, "GetCardVerifyCode", token)
+}
+
+// VerifyCard records the call and returns the queued *payment.VerifyCardResponse.
+func (m *MockClient) VerifyCard(_ context.Context, token, code string, _ ...payment.RequestOption) (*payment.VerifyCardResponse, error) {
+	return next[payment.VerifyCardResponse](m, "VerifyCard", token, code)
+}
+
+// CheckCard records the call and returns the queued *payment.CheckCardResponse.
+func (m *MockClient) CheckCard(_ context.Context, token string, _ ...payment.RequestOption) (*payment.CheckCardResponse, error) {
+	return next[payment.CheckCardResponse](m, "CheckCard", token)
+}
+
+// RemoveCard records the call and returns the queued *payment.RemoveCardResponse.
+func (m *MockClient) RemoveCard(_ context.Context, token string, _ ...payment.RequestOption) (*payment.RemoveCardResponse, error) {
+	return next[payment.RemoveCardResponse](m, "RemoveCard", token)
+}
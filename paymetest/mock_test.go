@@ -0,0 +1,85 @@
+package paymetest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	payment "payme.kisuke.uz"
+)
+
+// chargeOrder is merchant code under test depending only on payment.PaymeClient.
+func chargeOrder(ctx context.Context, client payment.PaymeClient, orderID string, amount int64, token string) (string, error) {
+	created, err := client.CreateReceipt(ctx, amount, map[string]interface{}{"order_id": orderID}, "order "+orderID, nil)
+	if err != nil {
+		return "", err
+	}
+	if _, err := client.PayReceipt(ctx, created.Receipt.ID, token); err != nil {
+		return "", err
+	}
+	return created.Receipt.ID, nil
+}
+
+func TestMockClientReturnsQueuedResponsesAndRecordsCalls(t *testing.T) {
+	mock := NewMockClient()
+	mock.Queue("CreateReceipt", &payment.CreateReceiptResponse{Receipt: &payment.Receipt{ID: "receipt-1"}}, nil)
+	mock.Queue("PayReceipt", &payment.PayReceiptResponse{}, nil)
+
+	receiptID, err := chargeOrder(context.Background(), mock, "42", 150000, "card-token-1")
+	if err != nil {
+		t.Fatalf("chargeOrder error: %v", err)
+	}
+	if receiptID != "receipt-1" {
+		t.Errorf("receipt id = %q, want receipt-1", receiptID)
+	}
+
+	calls := mock.Calls()
+	if len(calls) != 2 || calls[0].Method != "CreateReceipt" || calls[1].Method != "PayReceipt" {
+		t.Fatalf("calls = %+v, want CreateReceipt and PayReceipt", calls)
+	}
+
+	create := mock.CallsTo("CreateReceipt")[0]
+	if amount := create.Args[0].(int64); amount != 150000 {
+		t.Errorf("amount = %d, want 150000", amount)
+	}
+	if account := create.Args[1].(map[string]interface{}); !reflect.DeepEqual(account, map[string]interface{}{"order_id": "42"}) {
+		t.Errorf("account = %v, want order_id 42", account)
+	}
+	if args := mock.CallsTo("PayReceipt")[0].Args; !reflect.DeepEqual(args, []interface{}{"receipt-1", "card-token-1"}) {
+		t.Errorf("PayReceipt args = %v, want receipt-1 and card-token-1", args)
+	}
+}
+
+func TestMockClientReturnsResponsesInOrder(t *testing.T) {
+	mock := NewMockClient()
+	paymeErr := &payment.Error{Code: payment.ReceiptNotFoundErrorCode, Message: "receipt not found"}
+	mock.Queue("CheckReceipt", &payment.CheckReceiptResponse{Receipt: &payment.Receipt{ID: "receipt-1"}}, nil)
+	mock.Queue("CheckReceipt", nil, paymeErr)
+
+	first, err := mock.CheckReceipt(context.Background(), "receipt-1")
+	if err != nil || first.Receipt.ID != "receipt-1" {
+		t.Errorf("first CheckReceipt = %+v, %v, want queued response", first, err)
+	}
+
+	second, err := mock.CheckReceipt(context.Background(), "receipt-2")
+	if second != nil || !errors.Is(err, paymeErr) {
+		t.Errorf("second CheckReceipt = %+v, %v, want queued error", second, err)
+	}
+
+	if _, err := mock.CheckReceipt(context.Background(), "receipt-3"); !errors.Is(err, ErrNoResponseQueued) {
+		t.Errorf("third CheckReceipt error = %v, want ErrNoResponseQueued", err)
+	}
+	if calls := mock.CallsTo("CheckReceipt"); len(calls) != 3 {
+		t.Errorf("recorded calls = %d, want 3", len(calls))
+	}
+}
+
+func TestMockClientRejectsWrongResponseType(t *testing.T) {
+	mock := NewMockClient()
+	mock.Queue("GetReceipt", &payment.CheckReceiptResponse{}, nil)
+
+	if _, err := mock.GetReceipt(context.Background(), "receipt-1"); err == nil {
+		t.Error("GetReceipt error = nil, want response type error")
+	}
+}
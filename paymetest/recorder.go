@@ -0,0 +1,262 @@
+package paymetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	payment "payme.kisuke.uz"
+)
+
+// ErrNoRecording is returned by ReplayTransport when there is no recorded response for the request.
+var ErrNoRecording = errors.New("paymetest: no recorded response")
+
+// Interaction is a recorded JSON-RPC request and response pair.
+type Interaction struct {
+	Method     string `json:"method"`
+	Params     string `json:"params"`
+	StatusCode int    `json:"status_code"`
+	Body       string `json:"body"`
+}
+
+// ScrubTokens returns a scrub function masking card tokens and numbers with payment.Redactor.
+// The extra keys are masked as well.
+func ScrubTokens(extraKeys ...string) func(string) string {
+	return payment.NewRedactor(extraKeys...).Redact
+}
+
+// RecordingTransport is an http.RoundTripper recording PayMe requests and responses to a file.
+// Set it as the Transport of ClientConfig.HTTPClient to record a sandbox session.
+type RecordingTransport struct {
+	// underlying transport, http.DefaultTransport if nil
+	Transport http.RoundTripper
+	// file the recordings are saved to after every request
+	Path string
+	// scrub function applied to params and response bodies, e.g. ScrubTokens()
+	Scrub func(string) string
+
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// NewRecordingTransport creates a recording transport saving to the path.
+// Returns a pointer to RecordingTransport.
+func NewRecordingTransport(path string, transport http.RoundTripper, scrub func(string) string) *RecordingTransport {
+	return &RecordingTransport{Transport: transport, Path: path, Scrub: scrub}
+}
+
+// RoundTrip sends the request with the underlying transport and records the interaction.
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	requestBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	responseBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	method, params, err := requestKey(requestBody, t.Scrub)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.interactions = append(t.interactions, Interaction{
+		Method:     method,
+		Params:     params,
+		StatusCode: resp.StatusCode,
+		Body:       scrub(string(responseBody), t.Scrub),
+	})
+
+	if err := t.save(); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// Interactions returns all recorded interactions in order.
+func (t *RecordingTransport) Interactions() []Interaction {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]Interaction(nil), t.interactions...)
+}
+
+// save writes the recorded interactions to the file.
+func (t *RecordingTransport) save() error {
+	if t.Path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(t.interactions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("paymetest: recordings marshal error: %w", err)
+	}
+	if err := os.WriteFile(t.Path, data, 0o644); err != nil {
+		return fmt.Errorf("paymetest: recordings write error: %w", err)
+	}
+	return nil
+}
+
+// ReplayTransport is an http.RoundTripper serving recorded responses keyed by method and params.
+// Responses of the same request are served in recorded order, and the response id is
+// replaced with the request id so the client accepts it.
+type ReplayTransport struct {
+	// scrub function applied to request params before matching, must be the one used for recording
+	Scrub func(string) string
+
+	mu           sync.Mutex
+	interactions map[string][]Interaction
+}
+
+// NewReplayTransport loads recordings from the file saved by RecordingTransport.
+// Returns a pointer to ReplayTransport or an error if the file cannot be read.
+func NewReplayTransport(path string, scrub func(string) string) (*ReplayTransport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("paymetest: recordings read error: %w", err)
+	}
+
+	var interactions []Interaction
+	if err := json.Unmarshal(data, &interactions); err != nil {
+		return nil, fmt.Errorf("paymetest: recordings unmarshal error: %w", err)
+	}
+
+	return NewReplayTransportFrom(interactions, scrub), nil
+}
+
+// NewReplayTransportFrom creates a replay transport serving the interactions.
+// Returns a pointer to ReplayTransport.
+func NewReplayTransportFrom(interactions []Interaction, scrub func(string) string) *ReplayTransport {
+	t := &ReplayTransport{Scrub: scrub, interactions: make(map[string][]Interaction)}
+	for _, interaction := range interactions {
+		key := interaction.Method + interaction.Params
+		t.interactions[key] = append(t.interactions[key], interaction)
+	}
+	return t
+}
+
+// RoundTrip serves the next recorded response for the request method and params.
+// Returns ErrNoRecording if there is no recorded response left.
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	requestBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	method, params, err := requestKey(requestBody, t.Scrub)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	key := method + params
+	queue := t.interactions[key]
+	if len(queue) == 0 {
+		t.mu.Unlock()
+		return nil, fmt.Errorf("%w for %s %s", ErrNoRecording, method, params)
+	}
+	t.interactions[key] = queue[1:]
+	t.mu.Unlock()
+
+	interaction := queue[0]
+
+	return &http.Response{
+		StatusCode: interaction.StatusCode,
+		Status:     http.StatusText(interaction.StatusCode),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(replaceResponseID([]byte(interaction.Body), requestBody))),
+		Request:    req,
+	}, nil
+}
+
+// readBody reads the body and replaces it with a new reader of the same bytes.
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil {
+		return nil, nil
+	}
+
+	data, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, fmt.Errorf("paymetest: body read error: %w", err)
+	}
+
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// requestKey returns the method and scrubbed canonical params JSON of the JSON-RPC request.
+// A batch request is keyed as "batch" with the whole array as params.
+func requestKey(body []byte, scrubFunc func(string) string) (string, string, error) {
+	var request struct {
+		Method string      `json:"method"`
+		Params interface{} `json:"params"`
+	}
+
+	method := "batch"
+	var params interface{}
+	if err := json.Unmarshal(body, &request); err == nil {
+		method, params = request.Method, request.Params
+	} else if err := json.Unmarshal(body, &params); err != nil {
+		return "", "", fmt.Errorf("paymetest: request unmarshal error: %w", err)
+	}
+
+	// Marshal again for canonical key order
+	canonical, err := json.Marshal(params)
+	if err != nil {
+		return "", "", fmt.Errorf("paymetest: params marshal error: %w", err)
+	}
+
+	return method, scrub(string(canonical), scrubFunc), nil
+}
+
+// replaceResponseID sets the id of the JSON-RPC response object to the request id.
+// Batch and non-JSON responses are returned as is.
+func replaceResponseID(responseBody, requestBody []byte) []byte {
+	var request struct {
+		ID json.RawMessage `json:"id"`
+	}
+	var response map[string]json.RawMessage
+	if json.Unmarshal(requestBody, &request) != nil || json.Unmarshal(responseBody, &response) != nil {
+		return responseBody
+	}
+	if _, ok := response["id"]; !ok || request.ID == nil {
+		return responseBody
+	}
+
+	response["id"] = request.ID
+	data, err := json.Marshal(response)
+	if err != nil {
+		return responseBody
+	}
+	return data
+}
+
+// scrub applies the scrub function if it is set.
+func scrub(s string, scrubFunc func(string) string) string {
+	if scrubFunc == nil {
+		return s
+	}
+	return scrubFunc(s)
+}
@@ -0,0 +1,116 @@
+package paymetest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	payment "payme.kisuke.uz"
+)
+
+const testToken = "card-token-1234567890abcdef"
+
+func TestRecordAndReplayRoundTrip(t *testing.T) {
+	var calls int32
+	server := newRPCServer(t, func(call rpcCall) interface{} {
+		atomic.AddInt32(&calls, 1)
+		return map[string]interface{}{
+			"receipt": payment.Receipt{ID: call.Params["id"].(string), State: int(payment.StatePaid), Amount: 150000},
+		}
+	})
+
+	path := filepath.Join(t.TempDir(), "session.json")
+	recorder := NewRecordingTransport(path, nil, ScrubTokens())
+	recording := newTestClient(t, server.URL, func(config *payment.ClientConfig) {
+		config.HTTPClient = http.Client{Transport: recorder}
+	})
+
+	recorded, err := recording.PayReceipt(context.Background(), "receipt-1", testToken)
+	if err != nil {
+		t.Fatalf("recorded PayReceipt error: %v", err)
+	}
+	server.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("recordings read error: %v", err)
+	}
+	if strings.Contains(string(data), testToken) {
+		t.Errorf("recordings contain the raw card token: %s", data)
+	}
+	if interactions := recorder.Interactions(); len(interactions) != 1 || interactions[0].Method != "receipts.pay" {
+		t.Errorf("interactions = %+v, want one receipts.pay", interactions)
+	}
+
+	replay, err := NewReplayTransport(path, ScrubTokens())
+	if err != nil {
+		t.Fatalf("NewReplayTransport error: %v", err)
+	}
+	replaying := newTestClient(t, server.URL, func(config *payment.ClientConfig) {
+		config.HTTPClient = http.Client{Transport: replay}
+	})
+
+	replayed, err := replaying.PayReceipt(context.Background(), "receipt-1", testToken)
+	if err != nil {
+		t.Fatalf("replayed PayReceipt error: %v", err)
+	}
+	if replayed.Receipt.ID != recorded.Receipt.ID || replayed.Receipt.Amount != recorded.Receipt.Amount {
+		t.Errorf("replayed receipt = %+v, want %+v", replayed.Receipt, recorded.Receipt)
+	}
+	if calls != 1 {
+		t.Errorf("server calls = %d, want 1", calls)
+	}
+}
+
+func TestReplayTransportMiss(t *testing.T) {
+	var attempts int32
+	replay := NewReplayTransportFrom([]Interaction{{
+		Method:     "receipts.check",
+		Params:     `{"id":"receipt-1"}`,
+		StatusCode: http.StatusOK,
+		Body:       `{"jsonrpc":"2.0","id":"1","result":{"state":0}}`,
+	}}, nil)
+	client := newTestClient(t, "http://payme.invalid", func(config *payment.ClientConfig) {
+		config.MaxRetries = 3
+		config.HTTPClient = http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			atomic.AddInt32(&attempts, 1)
+			return replay.RoundTrip(req)
+		})}
+	})
+
+	_, err := client.CheckReceipt(context.Background(), "receipt-2")
+	if !errors.Is(err, ErrNoRecording) {
+		t.Errorf("CheckReceipt error = %v, want ErrNoRecording", err)
+	}
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1, a replay miss is not retried", attempts)
+	}
+
+	if _, err := client.CheckReceipt(context.Background(), "receipt-1"); err != nil {
+		t.Errorf("recorded CheckReceipt error: %v", err)
+	}
+}
+
+func TestScrubTokens(t *testing.T) {
+	scrub := ScrubTokens("phone")
+
+	got := scrub(`{"token":"` + testToken + `","phone":"998901234567","id":"receipt-1"}`)
+	if strings.Contains(got, testToken) || strings.Contains(got, "998901234567") {
+		t.Errorf("scrubbed = %s, want token and phone masked", got)
+	}
+	if !strings.Contains(got, `"id":"receipt-1"`) {
+		t.Errorf("scrubbed = %s, want other values kept", got)
+	}
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
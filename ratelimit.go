@@ -0,0 +1,94 @@
+package payment
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimiter limits the rate of requests sent to PayMe API.
+// Wait blocks until a request is allowed or the context is done.
+type RateLimiter interface {
+	Wait(ctx context.Context) error
+}
+
+// RateLimit contains token bucket parameters for the default rate limiter.
+// RequestsPerSecond is the refill rate and Burst is the bucket size.
+type RateLimit struct {
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	Burst             int     `json:"burst"`
+}
+
+// TokenBucketLimiter is the default token bucket RateLimiter.
+// It is safe for concurrent use by multiple goroutines.
+type TokenBucketLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	// no limit for non-positive or infinite rate
+	unlimited bool
+}
+
+// NewTokenBucketLimiter creates a new token bucket rate limiter.
+// The bucket starts full and is refilled with requestsPerSecond tokens per second.
+// A zero, negative, NaN or infinite requestsPerSecond means no limit.
+// Returns a pointer to TokenBucketLimiter.
+func NewTokenBucketLimiter(requestsPerSecond float64, burst int) *TokenBucketLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &TokenBucketLimiter{
+		rate:      requestsPerSecond,
+		burst:     float64(burst),
+		tokens:    float64(burst),
+		last:      time.Now(),
+		unlimited: !(requestsPerSecond > 0) || math.IsInf(requestsPerSecond, 1),
+	}
+}
+
+// Wait blocks until a token is available or the context is done.
+// Returns the context error if the context is done before a token is available.
+func (l *TokenBucketLimiter) Wait(ctx context.Context) error {
+	if l.unlimited {
+		return nil
+	}
+
+	for {
+		l.mu.Lock()
+
+		now := time.Now()
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+
+		wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// WithRateLimiter sets a custom rate limiter for the client.
+// It replaces the limiter created from ClientConfig.RateLimit.
+// It is not synchronized with running requests, so it must only be called right after
+// NewClient, before the client is shared between goroutines. Prefer ClientConfig.RateLimiter.
+// Returns the client for chaining.
+func (c *Client) WithRateLimiter(l RateLimiter) *Client {
+	c.RateLimiter = l
+	return c
+}
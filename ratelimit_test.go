@@ -0,0 +1,44 @@
+package payment
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketLimiterWithoutRateIsUnlimited(t *testing.T) {
+	for _, rate := range []float64{0, -1, math.NaN(), math.Inf(1), math.Inf(-1)} {
+		limiter := NewTokenBucketLimiter(rate, 1)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		for i := 0; i < 100; i++ {
+			if err := limiter.Wait(ctx); err != nil {
+				t.Fatalf("rate %v: Wait error: %v", rate, err)
+			}
+		}
+		cancel()
+	}
+}
+
+func TestTokenBucketLimiterWaitsForToken(t *testing.T) {
+	limiter := NewTokenBucketLimiter(50, 1)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait error: %v", err)
+		}
+	}
+
+	// The first token is in the full bucket, two more take 20ms each
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("elapsed = %v, want about 40ms for 3 requests at 50 per second", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.Wait(ctx); err != context.Canceled {
+		t.Errorf("Wait error = %v, want context.Canceled with an empty bucket", err)
+	}
+}
@@ -3,7 +3,9 @@ package payment
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 )
 
@@ -11,31 +13,194 @@ import (
 
 const (
 	PayForOrderReasonID = "6"
+	P2PTransferReasonID = "7"
 	Description         = "Merchant transaction for order - %s"
+	P2PDescription      = "P2P transfer for order - %s"
 )
 
+// descriptionTemplate returns the merchant receipt description template.
+// Description is used for clients created without NewClient.
+func (c *Client) descriptionTemplate() string {
+	if c.DescriptionTemplate == "" {
+		return Description
+	}
+	return c.DescriptionTemplate
+}
+
+// ===== RECEIPT STATES =====
+
+// ReceiptState represents the state of a receipt in PayMe system.
+type ReceiptState int
+
+const (
+	StateCreated  ReceiptState = 0
+	StatePaid     ReceiptState = 1
+	StateCanceled ReceiptState = -1
+	StateExpired  ReceiptState = -2
+)
+
+// String returns the human-readable name of the receipt state.
+func (s ReceiptState) String() string {
+	switch s {
+	case StateCreated:
+		return "created"
+	case StatePaid:
+		return "paid"
+	case StateCanceled:
+		return "canceled"
+	case StateExpired:
+		return "expired"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// IsFinal checks if the receipt state cannot change anymore.
+// Returns true for paid, canceled and expired states.
+func (s ReceiptState) IsFinal() bool {
+	return s == StatePaid || s == StateCanceled || s == StateExpired
+}
+
+// Status returns the typed state of the receipt.
+// It is named Status because Receipt already has the State field.
+func (r *Receipt) Status() ReceiptState {
+	return ReceiptState(r.State)
+}
+
+// FormattedAmount returns the receipt amount formatted with the receipt currency like "100.00 сум".
+func (r *Receipt) FormattedAmount() string {
+	return FormatAmount(r.Amount, r.Currency)
+}
+
+// CreatedAt returns the receipt create time, or zero time if it is not set.
+func (r *Receipt) CreatedAt() time.Time {
+	return timeFromMillis(r.CreateTime)
+}
+
+// PaidAt returns the receipt pay time, or zero time if the receipt is not paid.
+func (r *Receipt) PaidAt() time.Time {
+	return timeFromMillis(r.PayTime)
+}
+
+// String returns a short summary of the receipt for display.
+func (r *Receipt) String() string {
+	summary := fmt.Sprintf("receipt %s %s %s", r.ID, r.Status(), r.FormattedAmount())
+	if createdAt := r.CreatedAt(); !createdAt.IsZero() {
+		summary += " created " + createdAt.Format(time.DateTime)
+	}
+	if paidAt := r.PaidAt(); !paidAt.IsZero() {
+		summary += " paid " + paidAt.Format(time.DateTime)
+	}
+	return summary
+}
+
+// ReceiptJSON is a human friendly JSON view of Receipt for merchant APIs.
+// Timestamps are RFC3339 strings and amounts are decimal strings in som,
+// while Receipt itself keeps the PayMe wire format with epoch milliseconds and tiyin.
+type ReceiptJSON struct {
+	ID          string                 `json:"id"`
+	State       string                 `json:"state"`
+	StateCode   int                    `json:"state_code"`
+	Amount      string                 `json:"amount"`
+	AmountTiyin int64                  `json:"amount_tiyin"`
+	Currency    int                    `json:"currency,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Account     map[string]interface{} `json:"account,omitempty"`
+	CreatedAt   string                 `json:"created_at,omitempty"`
+	PaidAt      string                 `json:"paid_at,omitempty"`
+	CanceledAt  string                 `json:"canceled_at,omitempty"`
+}
+
+// View returns the human friendly JSON view of the receipt.
+// Marshal Receipt directly to keep the canonical PayMe form.
+func (r *Receipt) View() ReceiptJSON {
+	currency := r.Currency
+	if currency == 0 {
+		currency = CurrencyUZS
+	}
+
+	// Same UZS fallback as TiyinToSomIn for unknown currencies
+	view := ReceiptJSON{
+		ID:          r.ID,
+		State:       r.Status().String(),
+		StateCode:   r.State,
+		Amount:      fmt.Sprintf("%.*f", currencyDecimals(currency), TiyinToSomIn(r.Amount, currency)),
+		AmountTiyin: r.Amount,
+		Currency:    r.Currency,
+		Description: r.Description,
+		CreatedAt:   formatRFC3339(r.CreateTime),
+		PaidAt:      formatRFC3339(r.PayTime),
+		CanceledAt:  formatRFC3339(r.CancelTime),
+	}
+
+	if len(r.Account) > 0 {
+		view.Account = make(map[string]interface{}, len(r.Account))
+		for _, account := range r.Account {
+			view.Account[account.Name] = account.Value
+		}
+	}
+
+	return view
+}
+
+// formatRFC3339 formats a PayMe timestamp in milliseconds as RFC3339 string.
+// Returns an empty string for zero timestamp.
+func formatRFC3339(millis int64) string {
+	if millis == 0 {
+		return ""
+	}
+	return time.UnixMilli(millis).Format(time.RFC3339)
+}
+
+// timeFromMillis converts a PayMe timestamp in milliseconds to time.Time.
+// Returns zero time for zero timestamp.
+func timeFromMillis(millis int64) time.Time {
+	if millis == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(millis)
+}
+
 // CreateMerchantReceipt creates a merchant receipt with dynamic account field mapping.
 // It uses the client's RequisiteName configuration to set the account identifier.
 // This method is useful when the account field name varies between different systems.
 // Returns the created receipt ID as string or an error.
-func (c *Client) CreateMerchantReceipt(ctx context.Context, data PaymentDetails) (string, error) {
+func (c *Client) CreateMerchantReceipt(ctx context.Context, data PaymentDetails, opts ...RequestOption) (string, error) {
 	requestID := fmt.Sprintf("ReceiptsCreate:MerchantTransaction:%s", data.Client.OrderID)
 
+	// Check the som amount before conversion to avoid overflow
+	if data.Amount <= 0 || data.Amount > MaxAmount/100 {
+		return "", ErrInvalidAmount
+	}
+
+	if data.Currency != 0 && !IsValidCurrency(data.Currency) {
+		return "", fmt.Errorf("unsupported currency %d: %w", data.Currency, ErrInvalidParams)
+	}
+
 	amountInTiyin := FromSomToTiyin(data.Amount)
 
+	// Extra fields never replace the requisite, card_id and reason fields
+	account := make(map[string]interface{}, len(data.ExtraAccount)+3)
+	for key, value := range data.ExtraAccount {
+		account[key] = value
+	}
+	account[c.RequisiteName] = data.Client.OrderID
+	account["card_id"] = data.Client.CardData.ID
+	account["reason"] = PayForOrderReasonID // payment for order
+
 	receiptParams := map[string]interface{}{
-		"amount": amountInTiyin,
-		"account": map[string]interface{}{
-			c.RequisiteName: data.Client.OrderID,
-			"card_id":       data.Client.CardData.ID,
-			"reason":        PayForOrderReasonID, // payment for order
-		},
-		"description": fmt.Sprintf(Description, data.Client.OrderID),
+		"amount":      amountInTiyin,
+		"account":     c.mergeAccount(account),
+		"description": fmt.Sprintf(c.descriptionTemplate(), data.Client.OrderID),
+	}
+
+	if data.Currency != 0 {
+		receiptParams["currency"] = data.Currency
 	}
 
-	resp, err := c.sendRequest(ctx, requestID, "receipts.create", receiptParams, false)
+	resp, err := c.sendRequest(ctx, requestID, "receipts.create", receiptParams, false, opts...)
 	if err != nil {
-		return "", fmt.Errorf("failed receipts create (error - %v request-id - %s response - %v)", err, requestID, resp)
+		return "", fmt.Errorf("failed receipts create (request-id - %s): %w", requestID, err)
 	}
 
 	var result CreateReceiptResponse
@@ -46,11 +211,13 @@ func (c *Client) CreateMerchantReceipt(ctx context.Context, data PaymentDetails)
 		}
 	}
 
+	if result.Receipt == nil {
+		return "", fmt.Errorf("failed receipts create (request-id - %s): %w", requestID, ErrReceiptNotFound)
+	}
+
 	createdReceiptsID := result.Receipt.ID
 
-	if c.Logger != nil {
-		c.Logger.Printf("receipts created for order - %v request-id - %s transaction-id - %s", data.Client.OrderID, requestID, createdReceiptsID)
-	}
+	c.logf("receipts created for order - %v request-id - %s transaction-id - %s", data.Client.OrderID, requestID, createdReceiptsID)
 
 	return createdReceiptsID, nil
 }
@@ -58,7 +225,7 @@ func (c *Client) CreateMerchantReceipt(ctx context.Context, data PaymentDetails)
 // PayMerchantReceipt processes payment for an existing merchant receipt.
 // It uses the receipt ID and card token to complete the payment.
 // Returns the paid receipt ID as string or an error.
-func (c *Client) PayMerchantReceipt(ctx context.Context, data PaymentDetails, createdReceiptsID string) (string, error) {
+func (c *Client) PayMerchantReceipt(ctx context.Context, data PaymentDetails, createdReceiptsID string, opts ...RequestOption) (string, error) {
 
 	requestID := fmt.Sprintf("ReceiptsPay:%s", data.Client.OrderID)
 
@@ -67,9 +234,9 @@ func (c *Client) PayMerchantReceipt(ctx context.Context, data PaymentDetails, cr
 		"token": data.Client.CardData.Token,
 	}
 
-	resp, err := c.sendRequest(ctx, requestID, "receipts.pay", receiptParams, false)
+	resp, err := c.sendRequest(ctx, requestID, "receipts.pay", receiptParams, false, opts...)
 	if err != nil {
-		return "", fmt.Errorf("failed receipts pay (error - %v request-id - %s receipts-id %s response - %v)", err, requestID, createdReceiptsID, resp.Error)
+		return "", fmt.Errorf("failed receipts pay (request-id - %s receipts-id %s): %w", requestID, createdReceiptsID, err)
 	}
 
 	var result PayReceiptResponse
@@ -80,41 +247,106 @@ func (c *Client) PayMerchantReceipt(ctx context.Context, data PaymentDetails, cr
 		}
 	}
 
+	if result.Receipt == nil {
+		return "", fmt.Errorf("failed receipts pay (request-id - %s receipts-id %s): %w", requestID, createdReceiptsID, ErrReceiptNotFound)
+	}
+
 	paidReceiptsID := result.Receipt.ID
 
-	if c.Logger != nil {
-		c.Logger.Printf("receipts paid for order - %v request-id - %s transaction-id - %s", data.Client.OrderID, requestID, paidReceiptsID)
-	}
+	c.logf("receipts paid for order - %v request-id - %s transaction-id - %s", data.Client.OrderID, requestID, paidReceiptsID)
 
 	return paidReceiptsID, nil
 }
 
 // CreateAndPayMerchantReceipt creates a merchant receipt and immediately processes payment.
 // This is a convenience method that combines CreateMerchantReceipt and PayMerchantReceipt.
-// Returns the final receipt ID as string or an error.
-func (c *Client) CreateAndPayMerchantReceipt(ctx context.Context, data PaymentDetails) (string, error) {
+// If payment fails, the result still contains the created receipt ID and PayErr,
+// and the receipt is canceled if CancelOnPayFailure is set.
+// Returns CreateAndPayResult with the receipt ID, or the result with an error if payment failed.
+func (c *Client) CreateAndPayMerchantReceipt(ctx context.Context, data PaymentDetails, opts ...RequestOption) (*CreateAndPayResult, error) {
+	createdReceiptsID, err := c.CreateMerchantReceipt(ctx, data, opts...)
+	if err != nil {
+		return nil, err
+	}
 
-	createdReceiptsID, err := c.CreateMerchantReceipt(ctx, data)
+	result := &CreateAndPayResult{ReceiptID: createdReceiptsID}
+
+	paidReceiptsID, err := c.PayMerchantReceipt(ctx, data, createdReceiptsID, opts...)
 	if err != nil {
-		return "", err
+		return c.payFailed(ctx, result, err)
 	}
 
-	paidReceiptsID, err := c.PayMerchantReceipt(ctx, data, createdReceiptsID)
+	result.ReceiptID = paidReceiptsID
+
+	return result, nil
+}
+
+// P2PTransfer transfers money from the sender card to the receiver card.
+// It creates a P2P receipt for the receiver card token with receipts.p2p method
+// and pays it with the sender card token. Identical cards are rejected
+// with ErrP2PIdenticalCards before sending.
+// Returns PayReceiptResponse with payment details or an error.
+func (c *Client) P2PTransfer(ctx context.Context, from, to PaymentData, amount int64, opts ...RequestOption) (*PayReceiptResponse, error) {
+	// Validation
+	if err := c.validateAmount(amount); err != nil {
+		return nil, err
+	}
+	if err := c.validateCardToken(from.CardData.Token); err != nil {
+		return nil, err
+	}
+	if err := c.validateCardToken(to.CardData.Token); err != nil {
+		return nil, err
+	}
+	if from.CardData.Token == to.CardData.Token || (from.CardData.ID != "" && from.CardData.ID == to.CardData.ID) {
+		return nil, ErrP2PIdenticalCards
+	}
+
+	requestID := c.newRequestID("ReceiptsP2P")
+
+	receiptParams := map[string]interface{}{
+		"token":       to.CardData.Token,
+		"amount":      amount,
+		"description": fmt.Sprintf(P2PDescription, from.OrderID),
+	}
+
+	resp, err := c.sendRequest(ctx, requestID, "receipts.p2p", receiptParams, false, opts...)
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("create p2p receipt error: %w", err)
 	}
 
-	return paidReceiptsID, nil
+	// Parse result
+	var createResp CreateReceiptResponse
+	if resp.Result != nil {
+		resultBytes, _ := json.Marshal(resp.Result)
+		if err := json.Unmarshal(resultBytes, &createResp); err != nil {
+			return nil, fmt.Errorf("result unmarshal error: %w", err)
+		}
+	}
+	if createResp.Receipt == nil {
+		return nil, fmt.Errorf("create p2p receipt error: %w", ErrReceiptNotFound)
+	}
+
+	payResp, err := c.PayReceipt(ctx, createResp.Receipt.ID, from.CardData.Token, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("pay p2p receipt error: %w", err)
+	}
+
+	c.logf("p2p transfer for order - %v receipt-id - %s", from.OrderID, createResp.Receipt.ID)
+
+	return payResp, nil
 }
 
 // GetReceiptStatus retrieves the current state of a receipt.
 // It calls CheckReceipt internally and returns the state value.
-// Returns the receipt state as int or -1 if error occurs.
+// Returns the receipt state as int, or -1 with ErrEmptyResponse if the result has no receipt.
 func (c *Client) GetReceiptStatus(ctx context.Context, receiptID string) (int, error) {
 	resp, err := c.CheckReceipt(ctx, receiptID)
 	if err != nil {
 		return -1, err
 	}
+	if resp.Receipt == nil {
+		return -1, fmt.Errorf("check receipt %s result has no receipt: %w", receiptID, ErrEmptyResponse)
+	}
 
 	return resp.Receipt.State, nil
 }
@@ -128,7 +360,7 @@ func (c *Client) IsReceiptPaid(ctx context.Context, receiptID string) (bool, err
 		return false, err
 	}
 
-	return state == 1, nil // 1 = Paid
+	return ReceiptState(state) == StatePaid, nil
 }
 
 // IsReceiptCanceled checks if a receipt has been canceled.
@@ -140,7 +372,7 @@ func (c *Client) IsReceiptCanceled(ctx context.Context, receiptID string) (bool,
 		return false, err
 	}
 
-	return state == -1, nil // -1 = Canceled
+	return ReceiptState(state) == StateCanceled, nil
 }
 
 // IsReceiptExpired checks if a receipt has expired.
@@ -152,52 +384,354 @@ func (c *Client) IsReceiptExpired(ctx context.Context, receiptID string) (bool,
 		return false, err
 	}
 
-	return state == -2, nil // -2 = Expired
+	return ReceiptState(state) == StateExpired, nil
 }
 
-// CreateMultipleReceipts creates multiple receipts in a single call.
-// It processes each receipt in the slice and returns a list of created receipt IDs.
-// Returns a slice of receipt IDs and any error that occurred.
-func (c *Client) CreateMultipleReceipts(ctx context.Context, receipts []map[string]interface{}) ([]string, error) {
-	var receiptIDs []string
-
-	for _, receipt := range receipts {
-		amount, ok := receipt["amount"].(int64)
-		if !ok {
-			continue
+// WaitForReceiptPaid polls the receipt state until it becomes paid, canceled, or expired.
+// The delay between polls starts at pollInterval and doubles up to 8 times pollInterval.
+// Polling stops when the context is done.
+// Returns StatePaid, or the final state with ErrReceiptCanceled or ErrReceiptExpired,
+// ErrReceiptNotFound if the result has no receipt, or the context error.
+func (c *Client) WaitForReceiptPaid(ctx context.Context, receiptID string, pollInterval time.Duration, opts ...RequestOption) (ReceiptState, error) {
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+
+	maxInterval := 8 * pollInterval
+	interval := pollInterval
+
+	for {
+		resp, err := c.CheckReceipt(ctx, receiptID, opts...)
+		if err != nil {
+			return StateCreated, err
+		}
+		if resp.Receipt == nil {
+			return StateCreated, ErrReceiptNotFound
 		}
 
-		account, ok := receipt["account"].(map[string]interface{})
-		if !ok {
-			continue
+		state := resp.Receipt.Status()
+		switch state {
+		case StatePaid:
+			return state, nil
+		case StateCanceled:
+			return state, ErrReceiptCanceled
+		case StateExpired:
+			return state, ErrReceiptExpired
 		}
 
-		description, _ := receipt["description"].(string)
-		detail, _ := receipt["detail"].(map[string]interface{})
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return state, ctx.Err()
+		case <-timer.C:
+		}
 
-		resp, err := c.CreateReceipt(ctx, amount, account, description, detail)
-		if err != nil {
-			continue
+		interval = min(interval*2, maxInterval)
+	}
+}
+
+// ReceiptStatusUpdate is a receipt state emitted by WatchReceiptStatus.
+// Err is set only in the last update, when watching stopped because of an error.
+type ReceiptStatusUpdate struct {
+	State ReceiptState
+	Err   error
+}
+
+// WatchReceiptStatus polls the receipt state in a goroutine and emits the state each time it changes.
+// The delay between polls starts at one second and doubles up to eight seconds, retryable errors are polled again.
+// The channel is closed after a final state, after an update with Err of a non-retryable error
+// or ErrReceiptNotFound if the result has no receipt, or when the context is done.
+// Returns the update channel, or an error if the receipt id is invalid.
+func (c *Client) WatchReceiptStatus(ctx context.Context, receiptID string) (<-chan ReceiptStatusUpdate, error) {
+	// Validation
+	if err := c.validateReceiptID(receiptID); err != nil {
+		return nil, err
+	}
+
+	updates := make(chan ReceiptStatusUpdate, 1)
+
+	go func() {
+		defer close(updates)
+
+		const pollInterval = time.Second
+		maxInterval := 8 * pollInterval
+		interval := pollInterval
+		last := ReceiptState(0)
+		first := true
+
+		emit := func(update ReceiptStatusUpdate) bool {
+			select {
+			case updates <- update:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		stop := func(err error) {
+			c.logf("watch receipt-id - %s stopped - %v", receiptID, err)
+			emit(ReceiptStatusUpdate{State: last, Err: err})
 		}
 
-		receiptIDs = append(receiptIDs, resp.Receipt.ID)
+		for {
+			resp, err := c.CheckReceipt(ctx, receiptID)
+			switch {
+			case err != nil && ctx.Err() != nil:
+				return
+			case err != nil && IsRetryable(err):
+				// poll again after the delay
+			case err != nil:
+				stop(err)
+				return
+			case resp.Receipt == nil:
+				stop(fmt.Errorf("check receipt %s result has no receipt: %w", receiptID, ErrReceiptNotFound))
+				return
+			default:
+				state := resp.Receipt.Status()
+				if first || state != last {
+					if !emit(ReceiptStatusUpdate{State: state}) {
+						return
+					}
+					first, last = false, state
+				}
+				if state.IsFinal() {
+					return
+				}
+			}
+
+			timer := time.NewTimer(interval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			interval = min(interval*2, maxInterval)
+		}
+	}()
+
+	return updates, nil
+}
+
+// CreateMultipleReceipts creates multiple receipts in a single call.
+// It processes each receipt in the slice and reports the result of every item.
+// Results keep the order of the input, so they can be correlated by index.
+// Returns BatchReceiptResults with created receipt IDs or per-item errors.
+func (c *Client) CreateMultipleReceipts(ctx context.Context, receipts []map[string]interface{}) (BatchReceiptResults, error) {
+	results := make(BatchReceiptResults, 0, len(receipts))
+
+	for i, receipt := range receipts {
+		results = append(results, c.createBatchReceipt(ctx, i, receipt))
 	}
 
-	return receiptIDs, nil
+	return results, nil
+}
+
+// CreateMultipleReceiptsConcurrent creates multiple receipts using a bounded worker pool.
+// At most concurrency receipts are created at the same time, and the client rate limiter
+// is applied to every request. Scheduling stops once the context is done, and
+// unscheduled receipts get the context error.
+// Returns BatchReceiptResults in the input order and the context error if it was cancelled.
+func (c *Client) CreateMultipleReceiptsConcurrent(ctx context.Context, receipts []map[string]interface{}, concurrency int) (BatchReceiptResults, error) {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	results := make(BatchReceiptResults, len(receipts))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = c.createBatchReceipt(ctx, i, receipts[i])
+			}
+		}()
+	}
+
+	scheduled := 0
+schedule:
+	for scheduled < len(receipts) {
+		select {
+		case <-ctx.Done():
+			break schedule
+		case jobs <- scheduled:
+			scheduled++
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i := scheduled; i < len(receipts); i++ {
+		results[i] = BatchReceiptResult{Index: i, Err: ctx.Err()}
+	}
+
+	return results, ctx.Err()
+}
+
+// createBatchReceipt creates a single receipt of the batch.
+// Returns BatchReceiptResult with created receipt ID or error.
+func (c *Client) createBatchReceipt(ctx context.Context, index int, receipt map[string]interface{}) BatchReceiptResult {
+	result := BatchReceiptResult{Index: index}
+
+	amount, ok := receipt["amount"].(int64)
+	if !ok {
+		result.Err = fmt.Errorf("amount must be int64: %w", ErrInvalidParams)
+		return result
+	}
+
+	account, ok := receipt["account"].(map[string]interface{})
+	if !ok {
+		result.Err = fmt.Errorf("account must be map[string]interface{}: %w", ErrInvalidParams)
+		return result
+	}
+
+	description, _ := receipt["description"].(string)
+	detail, _ := receipt["detail"].(map[string]interface{})
+
+	resp, err := c.CreateReceipt(ctx, amount, account, description, detail)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	if resp.Receipt != nil {
+		result.ReceiptID = resp.Receipt.ID
+	}
+
+	return result
 }
 
 // CancelMultipleReceipts cancels multiple receipts in a single call.
 // It attempts to cancel each receipt and logs any errors that occur.
-// Returns nil if all receipts were processed, regardless of individual success.
-func (c *Client) CancelMultipleReceipts(ctx context.Context, receiptIDs []string) error {
+// Returns a map of failed receipt IDs to errors and all failures joined with errors.Join, or nil if all succeeded.
+func (c *Client) CancelMultipleReceipts(ctx context.Context, receiptIDs []string) (map[string]error, error) {
+	failed := make(map[string]error)
+	var errs []error
+
 	for _, receiptID := range receiptIDs {
 		_, err := c.CancelReceipt(ctx, receiptID)
 		if err != nil {
+			c.logf("Failed to cancel receipt %s: %v", receiptID, err)
 
-			if c.Logger != nil {
-				c.Logger.Printf("Failed to cancel receipt %s: %v", receiptID, err)
-			}
+			failed[receiptID] = err
+			errs = append(errs, fmt.Errorf("receipt %s cancel error: %w", receiptID, err))
+		}
+	}
+
+	return failed, errors.Join(errs...)
+}
+
+// GetReceiptsByIDs retrieves receipts by ids with receipts.get calls sent in a single batch request.
+// Duplicate ids are fetched once, and invalid ids are reported in the error map without being sent.
+// Returns receipts and per-id errors keyed by receipt id, or an error if the whole batch failed.
+func (c *Client) GetReceiptsByIDs(ctx context.Context, receiptIDs []string, opts ...RequestOption) (map[string]*Receipt, map[string]error, error) {
+	receipts := make(map[string]*Receipt, len(receiptIDs))
+	failed := make(map[string]error)
+
+	// Validation
+	batch := c.NewBatch()
+	seen := make(map[string]bool, len(receiptIDs))
+	for _, receiptID := range receiptIDs {
+		if seen[receiptID] {
+			continue
+		}
+		seen[receiptID] = true
+
+		if err := c.validateReceiptID(receiptID); err != nil {
+			failed[receiptID] = err
+			continue
+		}
+		batch.Add(receiptID, "receipts.get", map[string]interface{}{"id": receiptID})
+	}
+
+	if batch.Len() == 0 {
+		return receipts, failed, nil
+	}
+
+	results, err := batch.SendBatch(ctx, opts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get receipts batch error: %w", err)
+	}
+
+	// Parse result
+	for receiptID, result := range results {
+		var resp GetReceiptResponse
+		if err := result.Decode(&resp); err != nil {
+			failed[receiptID] = err
+			continue
 		}
+		if resp.Receipt == nil {
+			failed[receiptID] = ErrReceiptNotFound
+			continue
+		}
+		receipts[receiptID] = resp.Receipt
+	}
+
+	return receipts, failed, nil
+}
+
+// RefundReceipt refunds a paid receipt by canceling it with the reason.
+// It checks the receipt is paid with CheckReceipt, since canceling a paid receipt reverses the payment.
+// Returns RefundResult with the cancel time, ErrReceiptNotFound, or ErrReceiptNotPaid if the receipt is not paid.
+func (c *Client) RefundReceipt(ctx context.Context, receiptID string, reason int) (*RefundResult, error) {
+	// Validation
+	if !IsValidCancelReason(reason) {
+		return nil, fmt.Errorf("invalid cancel reason %d: %w", reason, ErrInvalidParams)
+	}
+
+	checkResp, err := c.CheckReceipt(ctx, receiptID)
+	if err != nil {
+		return nil, fmt.Errorf("check receipt error: %w", err)
+	}
+	if checkResp.Receipt == nil {
+		return nil, ErrReceiptNotFound
+	}
+
+	state := ReceiptState(checkResp.Receipt.State)
+	if state != StatePaid {
+		return nil, fmt.Errorf("receipt %s is %s, nothing to refund: %w", receiptID, state, ErrReceiptNotPaid)
+	}
+
+	cancelResp, err := c.CancelReceiptWithReason(ctx, receiptID, reason)
+	if err != nil {
+		return nil, fmt.Errorf("cancel receipt error: %w", err)
+	}
+
+	result := &RefundResult{
+		ReceiptID: receiptID,
+		Amount:    checkResp.Receipt.Amount,
+		Reason:    reason,
+	}
+	if cancelResp.Receipt != nil {
+		result.Amount = cancelResp.Receipt.Amount
+		result.CancelTime = cancelResp.Receipt.CancelTime
+		result.State = cancelResp.Receipt.State
+	}
+
+	return result, nil
+}
+
+// VerifyPaidAmount checks that the receipt is paid with exactly the expected amount in tiyin.
+// It is a post-payment check against tampered amounts.
+// Returns ErrReceiptNotPaid if the receipt is not paid, *AmountMismatchError if amounts differ, or nil.
+func (c *Client) VerifyPaidAmount(ctx context.Context, receiptID string, expected int64) error {
+	resp, err := c.GetReceipt(ctx, receiptID)
+	if err != nil {
+		return fmt.Errorf("get receipt error: %w", err)
+	}
+	if resp.Receipt == nil {
+		return ErrReceiptNotFound
+	}
+
+	if state := resp.Receipt.Status(); state != StatePaid {
+		return fmt.Errorf("receipt %s is %s: %w", receiptID, state, ErrReceiptNotPaid)
+	}
+	if resp.Receipt.Amount != expected {
+		return &AmountMismatchError{ReceiptID: receiptID, Expected: expected, Actual: resp.Receipt.Amount}
 	}
 
 	return nil
@@ -213,50 +747,106 @@ func (c *Client) GetReceiptsByDateRange(ctx context.Context, from, to time.Time,
 	return c.GetAllReceipts(ctx, fromTimestamp, toTimestamp, limit)
 }
 
-// GetReceiptsByState retrieves receipts filtered by their state.
-// Since PayMe API doesn't directly support state filtering, it fetches all receipts
-// and filters them locally by the specified state.
+// GetReceiptsByState retrieves receipts within the time range filtered by their state.
+// Since PayMe API doesn't directly support state filtering, it pages through all receipts
+// with IterateReceipts and filters them on the client side.
 // Returns GetAllReceiptsResponse with filtered receipts.
-func (c *Client) GetReceiptsByState(ctx context.Context, state int, limit int) (*GetAllReceiptsResponse, error) {
-	from := time.Now().AddDate(0, -1, 0).UnixMilli()
-	to := time.Now().UnixMilli()
+func (c *Client) GetReceiptsByState(ctx context.Context, state int, from, to time.Time, pageSize int) (*GetAllReceiptsResponse, error) {
+	return c.filterReceipts(ctx, from, to, pageSize, func(receipt *Receipt) bool {
+		return receipt.State == state
+	})
+}
 
-	resp, err := c.GetAllReceipts(ctx, from, to, limit)
-	if err != nil {
-		return nil, err
-	}
+// AmountBounds defines if amount range bounds are included in the range.
+type AmountBounds int
 
-	var filteredReceipts []*Receipt
-	for _, receipt := range resp.Receipts {
-		if receipt.State == state {
-			filteredReceipts = append(filteredReceipts, receipt)
-		}
+const (
+	// BoundsInclusive matches amounts min <= amount <= max
+	BoundsInclusive AmountBounds = iota
+	// BoundsExclusive matches amounts min < amount < max
+	BoundsExclusive
+)
+
+// GetReceiptsByAmountRange retrieves receipts within the time range filtered by amount range.
+// Amounts are compared in tiyin, and bounds defines if minAmount and maxAmount are included.
+// Since PayMe API doesn't directly support amount filtering, it pages through all receipts
+// with IterateReceipts and filters them on the client side.
+// Returns GetAllReceiptsResponse with filtered receipts, or ErrInvalidParams if minAmount > maxAmount.
+func (c *Client) GetReceiptsByAmountRange(ctx context.Context, minAmount, maxAmount Money, bounds AmountBounds, from, to time.Time, pageSize int) (*GetAllReceiptsResponse, error) {
+	// Validation
+	if minAmount > maxAmount {
+		return nil, fmt.Errorf("min amount %s is greater than max amount %s: %w", minAmount, maxAmount, ErrInvalidParams)
 	}
 
-	return &GetAllReceiptsResponse{
-		Receipts: filteredReceipts,
-	}, nil
+	return c.filterReceipts(ctx, from, to, pageSize, func(receipt *Receipt) bool {
+		amount := MoneyFromTiyin(receipt.Amount)
+		if bounds == BoundsExclusive {
+			return amount > minAmount && amount < maxAmount
+		}
+		return amount >= minAmount && amount <= maxAmount
+	})
 }
 
-// GetReceiptsByAmountRange retrieves receipts filtered by amount range.
-// Since PayMe API doesn't directly support amount filtering, it fetches all receipts
-// and filters them locally by the specified amount range.
-// Returns GetAllReceiptsResponse with filtered receipts.
-func (c *Client) GetReceiptsByAmountRange(ctx context.Context, minAmount, maxAmount int64, limit int) (*GetAllReceiptsResponse, error) {
-	from := time.Now().AddDate(0, -1, 0).UnixMilli()
-	to := time.Now().UnixMilli()
+// FindReceiptByOrderID finds the receipt of the merchant order created within the window before now.
+// It pages through receipts with IterateReceipts and matches the account value of RequisiteName.
+// If several receipts match, the first found one is returned.
+// Returns the matching Receipt, or ErrReceiptNotFound if none match.
+func (c *Client) FindReceiptByOrderID(ctx context.Context, orderID string, window time.Duration) (*Receipt, error) {
+	// Validation
+	if orderID == "" {
+		return nil, fmt.Errorf("order id is empty: %w", ErrInvalidParams)
+	}
 
-	resp, err := c.GetAllReceipts(ctx, from, to, limit)
-	if err != nil {
+	to := c.now()
+	from := to.Add(-window)
+
+	it := c.IterateReceipts(ctx, from, to, 0)
+	for receipt, ok := it.Next(); ok; receipt, ok = it.Next() {
+		for _, account := range receipt.Account {
+			if account.Name == c.RequisiteName && fmt.Sprint(account.Value) == orderID {
+				return receipt, nil
+			}
+		}
+	}
+	if err := it.Err(); err != nil {
 		return nil, err
 	}
 
+	return nil, fmt.Errorf("receipt with order id %s: %w", orderID, ErrReceiptNotFound)
+}
+
+// SumPaidReceipts computes the total amount of paid receipts within the time range for settlement.
+// It pages through all receipts with IterateReceipts and sums amounts of paid receipts in tiyin.
+// Returns the total amount, the number of paid receipts, or the iteration error.
+func (c *Client) SumPaidReceipts(ctx context.Context, from, to time.Time) (total int64, count int, err error) {
+	it := c.IterateReceipts(ctx, from, to, 0)
+	for receipt, ok := it.Next(); ok; receipt, ok = it.Next() {
+		if receipt.Status() == StatePaid {
+			total += receipt.Amount
+			count++
+		}
+	}
+	if err := it.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	return total, count, nil
+}
+
+// filterReceipts pages through all receipts within the time range and keeps the matching ones.
+// Returns GetAllReceiptsResponse with matching receipts or the iteration error.
+func (c *Client) filterReceipts(ctx context.Context, from, to time.Time, pageSize int, match func(*Receipt) bool) (*GetAllReceiptsResponse, error) {
 	var filteredReceipts []*Receipt
-	for _, receipt := range resp.Receipts {
-		if receipt.Amount >= minAmount && receipt.Amount <= maxAmount {
+
+	it := c.IterateReceipts(ctx, from, to, pageSize)
+	for receipt, ok := it.Next(); ok; receipt, ok = it.Next() {
+		if match(receipt) {
 			filteredReceipts = append(filteredReceipts, receipt)
 		}
 	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
 
 	return &GetAllReceiptsResponse{
 		Receipts: filteredReceipts,
@@ -0,0 +1,541 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// merchantPaymentDetails returns merchant payment details of order-1 for 100 som.
+func merchantPaymentDetails() PaymentDetails {
+	return PaymentDetails{
+		Client: PaymentData{OrderID: "order-1", CardData: CardData{ID: "card-1", Token: "token-0123456789"}},
+		Amount: 100,
+	}
+}
+
+func TestMerchantReceiptErrorsKeepPaymeError(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		code     int
+		sentinel error
+		call     func(c *Client) error
+	}{
+		{
+			name:     "create",
+			method:   "receipts.create",
+			code:     InvalidAmountErrorCode,
+			sentinel: ErrInvalidAmount,
+			call: func(c *Client) error {
+				_, err := c.CreateMerchantReceipt(context.Background(), merchantPaymentDetails())
+				return err
+			},
+		},
+		{
+			name:     "pay",
+			method:   "receipts.pay",
+			code:     CardExpiredCode,
+			sentinel: ErrCardExpired,
+			call: func(c *Client) error {
+				_, err := c.PayMerchantReceipt(context.Background(), merchantPaymentDetails(), "receipt-1")
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+				if call.Method != tt.method {
+					t.Errorf("method = %s, want %s", call.Method, tt.method)
+				}
+				return nil, &Error{Code: tt.code, Message: "rejected"}
+			})
+			client := newTestClient(t, server.URL)
+
+			// Wrapped once more by the caller
+			err := fmt.Errorf("checkout: %w", tt.call(client))
+
+			if !errors.Is(err, tt.sentinel) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.sentinel)
+			}
+			var paymeErr *PaymeError
+			if !errors.As(err, &paymeErr) || paymeErr.Code != tt.code {
+				t.Errorf("errors.As PaymeError = %v, want code %d", paymeErr, tt.code)
+			}
+			if !IsPaymeError(err) || GetErrorCode(err) != tt.code {
+				t.Errorf("IsPaymeError = %t GetErrorCode = %d, want true %d", IsPaymeError(err), GetErrorCode(err), tt.code)
+			}
+			if IsRetryable(err) {
+				t.Errorf("IsRetryable(%v) = true, want false", err)
+			}
+		})
+	}
+}
+
+func TestCreateMerchantReceiptWithoutReceipt(t *testing.T) {
+	server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+		return map[string]interface{}{}, nil
+	})
+	client := newTestClient(t, server.URL)
+
+	_, err := client.CreateMerchantReceipt(context.Background(), merchantPaymentDetails())
+	if !errors.Is(err, ErrReceiptNotFound) {
+		t.Errorf("error = %v, want ErrReceiptNotFound", err)
+	}
+}
+
+func TestCreateMerchantReceiptAmountBounds(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount int64
+		tiyin  float64
+		err    error
+	}{
+		{"beyond int32", math.MaxInt32 + 1, 214748364800, nil},
+		{"max", MaxAmount / 100, 999999999900, nil},
+		{"over max", MaxAmount/100 + 1, 0, ErrInvalidAmount},
+		{"overflow", math.MaxInt64 / 10, 0, ErrInvalidAmount},
+		{"zero", 0, 0, ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []float64
+			server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+				sent = append(sent, call.Params["amount"].(float64))
+				return receiptResult(Receipt{ID: "receipt-1"}), nil
+			})
+			client := newTestClient(t, server.URL)
+
+			data := merchantPaymentDetails()
+			data.Amount = tt.amount
+			_, err := client.CreateMerchantReceipt(context.Background(), data)
+
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("error = %v, want %v", err, tt.err)
+				}
+				if len(sent) != 0 {
+					t.Errorf("sent %d requests, want none", len(sent))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateMerchantReceipt error: %v", err)
+			}
+			if len(sent) != 1 || sent[0] != tt.tiyin {
+				t.Errorf("sent amounts = %v, want [%v]", sent, tt.tiyin)
+			}
+		})
+	}
+}
+
+func TestReceiptStatusWithoutReceipt(t *testing.T) {
+	server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+		return map[string]interface{}{}, nil
+	})
+	client := newTestClient(t, server.URL)
+	ctx := context.Background()
+
+	if state, err := client.GetReceiptStatus(ctx, "receipt-1"); state != -1 || !errors.Is(err, ErrEmptyResponse) {
+		t.Errorf("GetReceiptStatus = %d, %v, want -1 and ErrEmptyResponse", state, err)
+	}
+
+	checks := map[string]func(context.Context, string) (bool, error){
+		"IsReceiptPaid":     client.IsReceiptPaid,
+		"IsReceiptCanceled": client.IsReceiptCanceled,
+		"IsReceiptExpired":  client.IsReceiptExpired,
+	}
+	for name, check := range checks {
+		if ok, err := check(ctx, "receipt-1"); ok || !errors.Is(err, ErrEmptyResponse) {
+			t.Errorf("%s = %v, %v, want false and ErrEmptyResponse", name, ok, err)
+		}
+	}
+}
+
+func TestCreateAndPayMerchantReceiptCancelsAfterCallerCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var methods []string
+	server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+		methods = append(methods, call.Method)
+		if call.Method == "receipts.pay" {
+			// The caller gives up while the payment is in flight
+			cancel()
+			time.Sleep(50 * time.Millisecond)
+		}
+		return receiptResult(Receipt{ID: "receipt-1"}), nil
+	})
+	client := newTestClient(t, server.URL, func(config *ClientConfig) {
+		config.CancelOnPayFailure = true
+	})
+
+	result, err := client.CreateAndPayMerchantReceipt(ctx, merchantPaymentDetails())
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want context.Canceled", err)
+	}
+	if !result.Canceled {
+		t.Error("Canceled = false, want the receipt canceled with a detached context")
+	}
+
+	want := []string{"receipts.create", "receipts.pay", "receipts.cancel"}
+	if fmt.Sprint(methods) != fmt.Sprint(want) {
+		t.Errorf("methods = %v, want %v", methods, want)
+	}
+}
+
+func TestCreateAndPayMerchantReceiptForwardsOptions(t *testing.T) {
+	server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+		time.Sleep(100 * time.Millisecond)
+		return receiptResult(Receipt{ID: "receipt-1"}), nil
+	})
+	client := newTestClient(t, server.URL)
+
+	_, err := client.CreateAndPayMerchantReceipt(context.Background(), merchantPaymentDetails(), WithTimeout(10*time.Millisecond))
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("error = %v, want ErrTimeout from the request timeout option", err)
+	}
+}
+
+func TestP2PTransfer(t *testing.T) {
+	from := PaymentData{OrderID: "order-1", CardData: CardData{ID: "card-1", Token: "sender-token-0123"}}
+	to := PaymentData{CardData: CardData{ID: "card-2", Token: "receiver-token-0123"}}
+
+	var calls []rpcCall
+	server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+		calls = append(calls, call)
+		return receiptResult(Receipt{ID: "receipt-1", State: int(StatePaid)}), nil
+	})
+	client := newTestClient(t, server.URL)
+
+	resp, err := client.P2PTransfer(context.Background(), from, to, 50000)
+	if err != nil {
+		t.Fatalf("P2PTransfer error: %v", err)
+	}
+	if resp.Receipt == nil || resp.Receipt.ID != "receipt-1" {
+		t.Errorf("receipt = %+v, want receipt-1", resp.Receipt)
+	}
+
+	if len(calls) != 2 {
+		t.Fatalf("calls = %d, want 2", len(calls))
+	}
+	if calls[0].Method != "receipts.p2p" || calls[0].Params["token"] != to.CardData.Token {
+		t.Errorf("first call = %s %v, want receipts.p2p with receiver token", calls[0].Method, calls[0].Params)
+	}
+	if calls[1].Method != "receipts.pay" || calls[1].Params["token"] != from.CardData.Token || calls[1].Params["id"] != "receipt-1" {
+		t.Errorf("second call = %s %v, want receipts.pay with sender token", calls[1].Method, calls[1].Params)
+	}
+}
+
+func TestP2PTransferWithoutReceipt(t *testing.T) {
+	server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+		return map[string]interface{}{}, nil
+	})
+	client := newTestClient(t, server.URL)
+
+	from := PaymentData{CardData: CardData{Token: "sender-token-0123"}}
+	to := PaymentData{CardData: CardData{Token: "receiver-token-0123"}}
+
+	_, err := client.P2PTransfer(context.Background(), from, to, 50000)
+	if !errors.Is(err, ErrReceiptNotFound) {
+		t.Errorf("error = %v, want ErrReceiptNotFound", err)
+	}
+}
+
+func TestP2PTransferIdenticalCards(t *testing.T) {
+	server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+		t.Errorf("unexpected call %s", call.Method)
+		return nil, nil
+	})
+	client := newTestClient(t, server.URL)
+
+	card := PaymentData{CardData: CardData{ID: "card-1", Token: "sender-token-0123"}}
+
+	_, err := client.P2PTransfer(context.Background(), card, card, 50000)
+	if !errors.Is(err, ErrP2PIdenticalCards) {
+		t.Errorf("error = %v, want ErrP2PIdenticalCards", err)
+	}
+}
+
+func TestWaitForReceiptPaid(t *testing.T) {
+	states := []ReceiptState{StateCreated, StateCreated, StatePaid}
+	polls := 0
+	server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+		state := states[min(polls, len(states)-1)]
+		polls++
+		return receiptResult(Receipt{ID: "receipt-1", State: int(state)}), nil
+	})
+	client := newTestClient(t, server.URL)
+
+	state, err := client.WaitForReceiptPaid(context.Background(), "receipt-1", time.Millisecond)
+	if err != nil || state != StatePaid {
+		t.Errorf("WaitForReceiptPaid = %v, %v, want paid", state, err)
+	}
+	if polls != 3 {
+		t.Errorf("polls = %d, want 3", polls)
+	}
+}
+
+func TestWaitForReceiptPaidWithoutReceipt(t *testing.T) {
+	server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+		return map[string]interface{}{}, nil
+	})
+	client := newTestClient(t, server.URL)
+
+	_, err := client.WaitForReceiptPaid(context.Background(), "receipt-1", time.Millisecond)
+	if !errors.Is(err, ErrReceiptNotFound) {
+		t.Errorf("error = %v, want ErrReceiptNotFound", err)
+	}
+}
+
+func TestWaitForReceiptPaidForwardsOptions(t *testing.T) {
+	server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+		time.Sleep(100 * time.Millisecond)
+		return receiptResult(Receipt{ID: "receipt-1", State: int(StatePaid)}), nil
+	})
+	client := newTestClient(t, server.URL)
+
+	_, err := client.WaitForReceiptPaid(context.Background(), "receipt-1", time.Millisecond, WithTimeout(10*time.Millisecond))
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("error = %v, want ErrTimeout from the per-call timeout", err)
+	}
+}
+
+func TestCreateAndPayMerchantReceiptPayTransportError(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var call rpcCall
+		if err := json.NewDecoder(r.Body).Decode(&call); err != nil {
+			t.Errorf("request decode error: %v", err)
+			return
+		}
+		methods = append(methods, call.Method)
+
+		if call.Method == "receipts.pay" {
+			// Drop the connection without a response
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Errorf("hijack error: %v", err)
+				return
+			}
+			conn.Close()
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Response{ID: call.ID, Result: receiptResult(Receipt{ID: "receipt-1"})})
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL, func(config *ClientConfig) {
+		config.CancelOnPayFailure = true
+	})
+
+	result, err := client.CreateAndPayMerchantReceipt(context.Background(), merchantPaymentDetails())
+	if err == nil {
+		t.Fatal("error = nil, want pay error")
+	}
+	if result == nil {
+		t.Fatal("result = nil, want created receipt id")
+	}
+	if result.ReceiptID != "receipt-1" {
+		t.Errorf("ReceiptID = %q, want receipt-1", result.ReceiptID)
+	}
+	if result.PayErr == nil || !errors.Is(err, result.PayErr) {
+		t.Errorf("PayErr = %v, want the pay error %v", result.PayErr, err)
+	}
+	if !IsRetryable(result.PayErr) {
+		t.Errorf("IsRetryable(%v) = false, want transport error to be retryable", result.PayErr)
+	}
+	if !result.Canceled {
+		t.Error("Canceled = false, want the receipt canceled after pay failure")
+	}
+
+	want := []string{"receipts.create", "receipts.pay", "receipts.cancel"}
+	if fmt.Sprint(methods) != fmt.Sprint(want) {
+		t.Errorf("methods = %v, want %v", methods, want)
+	}
+}
+
+func TestVerifyPaidAmount(t *testing.T) {
+	tests := []struct {
+		name    string
+		receipt Receipt
+		want    error
+	}{
+		{"matching amount", Receipt{ID: "receipt-1", State: int(StatePaid), Amount: 500000}, nil},
+		{"different amount", Receipt{ID: "receipt-1", State: int(StatePaid), Amount: 100}, ErrAmountMismatch},
+		{"not paid", Receipt{ID: "receipt-1", State: int(StateCreated), Amount: 500000}, ErrReceiptNotPaid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+				return receiptResult(tt.receipt), nil
+			})
+			client := newTestClient(t, server.URL)
+
+			err := client.VerifyPaidAmount(context.Background(), "receipt-1", 500000)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyPaidAmountMismatchDetails(t *testing.T) {
+	server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+		return receiptResult(Receipt{ID: "receipt-1", State: int(StatePaid), Amount: 499900}), nil
+	})
+	client := newTestClient(t, server.URL)
+
+	err := client.VerifyPaidAmount(context.Background(), "receipt-1", 500000)
+
+	var mismatch *AmountMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("error = %v, want AmountMismatchError", err)
+	}
+	if mismatch.ReceiptID != "receipt-1" || mismatch.Expected != 500000 || mismatch.Actual != 499900 {
+		t.Errorf("mismatch = %+v, want receipt-1 expected 500000 actual 499900", mismatch)
+	}
+}
+
+func TestReceiptJSONRoundTrip(t *testing.T) {
+	raw := `{"_id":"receipt-1","create_time":1700000000000,"pay_time":1700000060000,"cancel_time":0,"state":1,"amount":150050,"currency":860,"account":[{"name":"order_id","value":"42"}]}`
+
+	var receipt Receipt
+	if err := json.Unmarshal([]byte(raw), &receipt); err != nil {
+		t.Fatalf("receipt unmarshal error: %v", err)
+	}
+
+	// Receipt keeps the canonical PayMe form
+	canonical, err := json.Marshal(&receipt)
+	if err != nil {
+		t.Fatalf("receipt marshal error: %v", err)
+	}
+	var again Receipt
+	if err := json.Unmarshal(canonical, &again); err != nil {
+		t.Fatalf("receipt unmarshal error: %v", err)
+	}
+	if again.CreateTime != 1700000000000 || again.PayTime != 1700000060000 || again.Amount != 150050 {
+		t.Errorf("round trip receipt = %+v, want canonical timestamps and amount", again)
+	}
+
+	data, err := json.Marshal(receipt.View())
+	if err != nil {
+		t.Fatalf("view marshal error: %v", err)
+	}
+	var view ReceiptJSON
+	if err := json.Unmarshal(data, &view); err != nil {
+		t.Fatalf("view unmarshal error: %v", err)
+	}
+
+	want := ReceiptJSON{
+		ID:          "receipt-1",
+		State:       StatePaid.String(),
+		StateCode:   1,
+		Amount:      "1500.50",
+		AmountTiyin: 150050,
+		Currency:    CurrencyUZS,
+		Account:     map[string]interface{}{"order_id": "42"},
+		CreatedAt:   time.UnixMilli(1700000000000).Format(time.RFC3339),
+		PaidAt:      time.UnixMilli(1700000060000).Format(time.RFC3339),
+	}
+	if fmt.Sprint(view) != fmt.Sprint(want) {
+		t.Errorf("view = %+v, want %+v", view, want)
+	}
+}
+
+func TestReceiptViewAmountDecimals(t *testing.T) {
+	tests := []struct {
+		name     string
+		currency int
+		want     string
+	}{
+		{name: "default currency", currency: 0, want: "1500.50"},
+		{name: "USD", currency: CurrencyUSD, want: "1500.50"},
+		{name: "unknown currency uses UZS units", currency: 999, want: "1500.50"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receipt := &Receipt{Amount: 150050, Currency: tt.currency}
+			if got := receipt.View().Amount; got != tt.want {
+				t.Errorf("amount = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatchReceiptStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler rpcHandler
+		want    []ReceiptStatusUpdate
+		wantErr error
+	}{
+		{
+			name: "final state",
+			handler: func(call rpcCall) (interface{}, *Error) {
+				return receiptResult(Receipt{ID: "receipt-1", State: int(StatePaid)}), nil
+			},
+			want: []ReceiptStatusUpdate{{State: StatePaid}},
+		},
+		{
+			name: "non-retryable error",
+			handler: func(call rpcCall) (interface{}, *Error) {
+				return nil, &Error{Code: ReceiptNotFoundErrorCode, Message: "receipt not found"}
+			},
+			wantErr: ErrReceiptNotFound,
+		},
+		{
+			name: "result without receipt",
+			handler: func(call rpcCall) (interface{}, *Error) {
+				return map[string]interface{}{}, nil
+			},
+			wantErr: ErrReceiptNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newRPCServer(t, tt.handler)
+			client := newTestClient(t, server.URL)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			updates, err := client.WatchReceiptStatus(ctx, "receipt-1")
+			if err != nil {
+				t.Fatalf("WatchReceiptStatus error: %v", err)
+			}
+
+			var got []ReceiptStatusUpdate
+			for update := range updates {
+				got = append(got, update)
+			}
+			if ctx.Err() != nil {
+				t.Fatal("watch did not stop before the context deadline")
+			}
+
+			if tt.wantErr != nil {
+				if len(got) != 1 || !errors.Is(got[0].Err, tt.wantErr) {
+					t.Errorf("updates = %+v, want one update with %v", got, tt.wantErr)
+				}
+				return
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("updates = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
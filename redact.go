@@ -0,0 +1,92 @@
+package payment
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultSensitiveKeys are the keys whose values are masked in log output.
+var DefaultSensitiveKeys = []string{"token", "number", "card_number", "pan"}
+
+// DefaultSensitiveAccountKeys are the receipt account keys whose values are masked in log output.
+var DefaultSensitiveAccountKeys = []string{"phone", "card_id", "sender_card_id"}
+
+// cardNumberPattern matches raw card numbers in log output.
+// Longer digit sequences are not matched, because millisecond timestamps
+// and request ids are 13 and 19 digits long.
+var cardNumberPattern = regexp.MustCompile(`\b\d{15,16}\b`)
+
+// Redactor masks card tokens, card numbers and other sensitive values in log output.
+// Values of sensitive keys in JSON ("token":"...") and Go map (token:...) form
+// are masked to the first and last 4 characters with MaskCardNumber.
+type Redactor struct {
+	pattern *regexp.Regexp
+}
+
+// NewRedactor creates a new redactor for DefaultSensitiveKeys and the extra keys.
+// Returns a pointer to Redactor.
+func NewRedactor(extraKeys ...string) *Redactor {
+	keys := append(append([]string(nil), DefaultSensitiveKeys...), extraKeys...)
+
+	quoted := make([]string, 0, len(keys))
+	for _, key := range keys {
+		quoted = append(quoted, regexp.QuoteMeta(key))
+	}
+
+	return &Redactor{
+		pattern: regexp.MustCompile(`("?(?:` + strings.Join(quoted, "|") + `)"?\s*[:=]\s*"?)([^",\s}\]]+)`),
+	}
+}
+
+// Redact masks sensitive values and card numbers in the string.
+// Returns the redacted string.
+func (r *Redactor) Redact(s string) string {
+	s = r.pattern.ReplaceAllStringFunc(s, func(match string) string {
+		parts := r.pattern.FindStringSubmatch(match)
+		return parts[1] + MaskCardNumber(parts[2])
+	})
+
+	return cardNumberPattern.ReplaceAllStringFunc(s, func(number string) string {
+		if !isValidLuhn(number) {
+			return number
+		}
+		return MaskCardNumber(number)
+	})
+}
+
+// MaskAccount returns a copy of the receipt account with masked values of sensitive keys.
+// DefaultSensitiveAccountKeys are used if keys are not given.
+// Returns the masked account, the given map is not modified.
+func MaskAccount(account map[string]interface{}, keys ...string) map[string]interface{} {
+	if len(keys) == 0 {
+		keys = DefaultSensitiveAccountKeys
+	}
+
+	masked := make(map[string]interface{}, len(account))
+	for key, value := range account {
+		masked[key] = value
+	}
+	for _, key := range keys {
+		if value, ok := masked[key]; ok && value != nil {
+			masked[key] = MaskCardNumber(fmt.Sprint(value))
+		}
+	}
+	return masked
+}
+
+// logf formats and writes a redacted log line if the logger is configured.
+func (c *Client) logf(format string, args ...interface{}) {
+	if c.Logger == nil {
+		return
+	}
+	c.Logger.Print(c.redact(fmt.Sprintf(format, args...)))
+}
+
+// redact masks sensitive values with the client redactor.
+func (c *Client) redact(s string) string {
+	if c.Redactor == nil {
+		return s
+	}
+	return c.Redactor.Redact(s)
+}
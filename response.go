@@ -1,5 +1,11 @@
 package payment
 
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
 // Response represents the base JSON-RPC response from PayMe API.
 // It contains the standard JSON-RPC fields and optional result or error.
 type Response struct {
@@ -18,6 +24,34 @@ type Error struct {
 	Origin  string `json:"origin,omitempty"`
 }
 
+// Error implements the error interface for PayMe API error.
+// Returns the error code and message as string.
+func (e *Error) Error() string {
+	return fmt.Sprintf("payme error (code - %d message - %s)", e.Code, e.Message)
+}
+
+// LocalizedMessage contains a Merchant API error message in Russian, Uzbek and English.
+type LocalizedMessage struct {
+	Ru string `json:"ru"`
+	Uz string `json:"uz"`
+	En string `json:"en"`
+}
+
+// MerchantError represents a Merchant API error reply.
+// PayMe shows the message to the payer in the language of the app,
+// and Data contains the invalid account field name for account errors.
+type MerchantError struct {
+	Code    int              `json:"code"`
+	Message LocalizedMessage `json:"message"`
+	Data    string           `json:"data,omitempty"`
+}
+
+// Error implements the error interface for Merchant API error.
+// Returns the error code and English message as string.
+func (e *MerchantError) Error() string {
+	return fmt.Sprintf("payme merchant error (code - %d message - %s)", e.Code, e.Message.En)
+}
+
 // Receipt represents a payment receipt in PayMe system.
 // It contains all receipt details including amount, status, timestamps, and metadata.
 type Receipt struct {
@@ -44,6 +78,7 @@ type Receipt struct {
 	Merchant     *ReceiptMerchant `json:"merchant,omitempty"`
 	Meta         *ReceiptMeta     `json:"meta,omitempty"`
 	ProcessingID interface{}      `json:"processing_id"`
+	FiscalData   FiscalDataList   `json:"fiscal_data,omitempty"`
 }
 
 // ReceiptCategory represents the category information for a receipt.
@@ -73,6 +108,53 @@ type ReceiptDetail struct {
 	Items    interface{} `json:"items"`
 }
 
+// ReceiptItem represents a fiscal line item of the receipt.
+// Price is per unit and discount is for the whole line, both in tiyin. Code is the IKPU code
+// and package code is the product package code from the tax committee catalog.
+type ReceiptItem struct {
+	Title       string `json:"title"`
+	Price       int64  `json:"price"`
+	Count       int    `json:"count"`
+	Code        string `json:"code"`
+	PackageCode string `json:"package_code"`
+	VatPercent  int    `json:"vat_percent"`
+	Discount    int64  `json:"discount,omitempty"`
+	Units       int    `json:"units,omitempty"`
+}
+
+// Total returns the line total of the item in tiyin, price multiplied by count minus discount.
+func (i ReceiptItem) Total() int64 {
+	return i.Price*int64(i.Count) - i.Discount
+}
+
+// ReceiptShipping represents the shipping information of the receipt.
+// It includes shipping title and price in tiyin.
+type ReceiptShipping struct {
+	Title string `json:"title"`
+	Price int64  `json:"price"`
+}
+
+// ReceiptDetailInput contains typed fiscal detail for receipt creation.
+// It includes receipt type, shipping and fiscal line items.
+type ReceiptDetailInput struct {
+	ReceiptType int              `json:"receipt_type"`
+	Shipping    *ReceiptShipping `json:"shipping,omitempty"`
+	Items       []ReceiptItem    `json:"items"`
+}
+
+// Total returns the sum of item line totals and shipping price in tiyin.
+// It must be equal to the receipt amount.
+func (d ReceiptDetailInput) Total() int64 {
+	var total int64
+	for _, item := range d.Items {
+		total += item.Total()
+	}
+	if d.Shipping != nil {
+		total += d.Shipping.Price
+	}
+	return total
+}
+
 // ReceiptAccount represents account information associated with a receipt.
 // It includes account name, title, value, and whether it's the main account.
 type ReceiptAccount struct {
@@ -140,11 +222,15 @@ type PaymentData struct {
 }
 
 // PaymentDetails contains complete payment information for merchant transactions.
-// It includes client and driver payment data along with amount.
+// It includes client and driver payment data along with amount in whole som
+// and optional currency code, UZS is used if currency is zero.
+// ExtraAccount contains additional account fields like branch_id for multi-requisite merchants.
 type PaymentDetails struct {
-	Client PaymentData `json:"client"`
-	Driver PaymentData `json:"driver"`
-	Amount int         `json:"amount"`
+	Client       PaymentData            `json:"client"`
+	Driver       PaymentData            `json:"driver"`
+	Amount       int64                  `json:"amount"`
+	Currency     int                    `json:"currency,omitempty"`
+	ExtraAccount map[string]interface{} `json:"extra_account,omitempty"`
 }
 
 // Account contains account information for receipt creation.
@@ -155,6 +241,14 @@ type Account struct {
 	Reason string `json:"reason"`
 }
 
+// AccountSplit contains a part of a split payment.
+// It includes the account requisite key and value, and the sub-amount in tiyin.
+type AccountSplit struct {
+	Key    string      `json:"key"`
+	Value  interface{} `json:"value"`
+	Amount int64       `json:"amount"`
+}
+
 // CreateReceiptResponse contains the response from receipts.create method.
 // It includes the created receipt details.
 type CreateReceiptResponse struct {
@@ -167,6 +261,19 @@ type PayReceiptResponse struct {
 	Receipt *Receipt `json:"receipt"`
 }
 
+// CreateAndPayResult contains the result of create and pay convenience methods.
+// ReceiptID is set even if payment failed, so the caller can retry the payment or cancel the receipt.
+type CreateAndPayResult struct {
+	// created receipt id
+	ReceiptID string
+	// paid receipt, nil if payment failed
+	Receipt *Receipt
+	// payment error, nil if the receipt is paid
+	PayErr error
+	// created receipt was canceled after payment failure
+	Canceled bool
+}
+
 // SendReceiptResponse contains the response from receipts.send method.
 // It includes the send confirmation and receipt details.
 type SendReceiptResponse struct {
@@ -185,6 +292,16 @@ type CheckReceiptResponse struct {
 	Receipt *Receipt `json:"receipt"`
 }
 
+// RefundResult contains the result of RefundReceipt.
+// It includes the refunded amount in tiyin and the cancel time in milliseconds.
+type RefundResult struct {
+	ReceiptID  string
+	Amount     int64
+	Reason     int
+	CancelTime int64
+	State      int
+}
+
 // GetReceiptResponse contains the response from receipts.get method.
 // It includes the complete receipt details and information.
 type GetReceiptResponse struct {
@@ -194,7 +311,81 @@ type GetReceiptResponse struct {
 // GetAllReceiptsResponse contains the response from receipts.get_all method.
 // It includes a list of receipts within the specified time range.
 type GetAllReceiptsResponse struct {
-	Receipts []*Receipt `json:"-"`
+	Receipts []*Receipt `json:"receipts"`
+}
+
+// UnmarshalJSON parses receipts.get_all result into GetAllReceiptsResponse.
+// PayMe returns a bare array of receipts, but the object-wrapped
+// form {"receipts": [...]} is also supported.
+func (r *GetAllReceiptsResponse) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+
+	if len(data) > 0 && data[0] == '[' {
+		return json.Unmarshal(data, &r.Receipts)
+	}
+
+	// Alias type prevents recursive UnmarshalJSON call
+	type wrapped GetAllReceiptsResponse
+	return json.Unmarshal(data, (*wrapped)(r))
+}
+
+// FiscalData represents the fiscal receipt data received from OFD.
+// It is sent to receipts.set_fiscal_data method to show the fiscal receipt and QR code in PayMe.
+type FiscalData struct {
+	// fiscal receipt id in OFD
+	ReceiptID int64 `json:"receipt_id"`
+	// OFD status code, 0 is success
+	StatusCode int `json:"status_code"`
+	// OFD message
+	Message string `json:"message,omitempty"`
+	// virtual cash register terminal id
+	TerminalID string `json:"terminal_id"`
+	// fiscal sign of the receipt
+	FiscalSign string `json:"fiscal_sign,omitempty"`
+	// url of the fiscal receipt QR code
+	QRCodeURL string `json:"qr_code_url"`
+	// fiscalization date in format YYYYMMDDhhmmss
+	Date string `json:"date"`
+}
+
+// Validate checks that required fiscal data fields are set.
+// Returns ErrInvalidParams wrapped with the missing field name.
+func (d FiscalData) Validate() error {
+	switch {
+	case d.ReceiptID == 0:
+		return fmt.Errorf("fiscal data receipt_id is required: %w", ErrInvalidParams)
+	case d.TerminalID == "":
+		return fmt.Errorf("fiscal data terminal_id is required: %w", ErrInvalidParams)
+	case d.QRCodeURL == "":
+		return fmt.Errorf("fiscal data qr_code_url is required: %w", ErrInvalidParams)
+	case d.Date == "":
+		return fmt.Errorf("fiscal data date is required: %w", ErrInvalidParams)
+	}
+	return nil
+}
+
+// FiscalDataList contains fiscal data set for the receipt, the last one is the latest.
+// PayMe returns it as a single object or an array, both forms are accepted.
+type FiscalDataList []FiscalData
+
+// UnmarshalJSON decodes fiscal data as an object or an array.
+func (l *FiscalDataList) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		*l = nil
+		return nil
+	}
+	if data[0] == '{' {
+		var fiscalData FiscalData
+		if err := json.Unmarshal(data, &fiscalData); err != nil {
+			return err
+		}
+		*l = FiscalDataList{fiscalData}
+		return nil
+	}
+
+	return json.Unmarshal(data, (*[]FiscalData)(l))
 }
 
 // SetFiscalDataResponse contains the response from receipts.set_fiscal_data method.
@@ -203,6 +394,62 @@ type SetFiscalDataResponse struct {
 	Receipt *Receipt `json:"receipt"`
 }
 
+// BatchReceiptResult contains the result of a single receipt in a batch.
+// It includes the input index, the created receipt ID and the error if creation failed.
+type BatchReceiptResult struct {
+	Index     int    `json:"index"`
+	ReceiptID string `json:"receipt_id,omitempty"`
+	Err       error  `json:"-"`
+}
+
+// BatchReceiptResults contains results of a batch in the input order.
+type BatchReceiptResults []BatchReceiptResult
+
+// AllSucceeded checks if every receipt of the batch was created.
+// Returns true if no result has an error.
+func (r BatchReceiptResults) AllSucceeded() bool {
+	for _, result := range r {
+		if result.Err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// ===== CARD TYPES =====
+
+// CreateCardResponse contains the response from cards.create method.
+// It includes the created card details with token.
+type CreateCardResponse struct {
+	Card *Card `json:"card"`
+}
+
+// GetVerifyCodeResponse contains the response from cards.get_verify_code method.
+// It includes the send confirmation, masked phone number and wait time.
+type GetVerifyCodeResponse struct {
+	Sent  bool   `json:"sent"`
+	Phone string `json:"phone"`
+	Wait  int64  `json:"wait"`
+}
+
+// VerifyCardResponse contains the response from cards.verify method.
+// It includes the verified card details.
+type VerifyCardResponse struct {
+	Card *Card `json:"card"`
+}
+
+// CheckCardResponse contains the response from cards.check method.
+// It includes the card details with recurrent and verify status.
+type CheckCardResponse struct {
+	Card *Card `json:"card"`
+}
+
+// RemoveCardResponse contains the response from cards.remove method.
+// It includes the removal confirmation.
+type RemoveCardResponse struct {
+	Success bool `json:"success"`
+}
+
 // ===== TRANSACTION TYPES =====
 
 // Transaction represents a payment transaction
@@ -217,3 +464,130 @@ type Transaction struct {
 	Currency    int    `json:"currency"`
 	ReceiptID   string `json:"receipt_id"`
 }
+
+// GetAllTransactionsResponse contains the response from transactions.get_all method.
+// It includes a list of transactions within the specified time range.
+type GetAllTransactionsResponse struct {
+	Transactions []*Transaction `json:"transactions"`
+}
+
+// UnmarshalJSON parses transactions.get_all result into GetAllTransactionsResponse.
+// Both a bare array and the object-wrapped form {"transactions": [...]} are supported.
+func (r *GetAllTransactionsResponse) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+
+	if len(data) > 0 && data[0] == '[' {
+		return json.Unmarshal(data, &r.Transactions)
+	}
+
+	// Alias type prevents recursive UnmarshalJSON call
+	type wrapped GetAllTransactionsResponse
+	return json.Unmarshal(data, (*wrapped)(r))
+}
+
+// ===== MERCHANT API TYPES =====
+
+// CheckPerformTransactionParams contains params of CheckPerformTransaction method.
+// It includes the amount in tiyin and the account fields of the order.
+type CheckPerformTransactionParams struct {
+	Amount  int64                  `json:"amount"`
+	Account map[string]interface{} `json:"account"`
+}
+
+// CheckPerformTransactionResult contains the result of CheckPerformTransaction method.
+// It tells PayMe whether the transaction is allowed.
+type CheckPerformTransactionResult struct {
+	Allow bool `json:"allow"`
+}
+
+// CreateTransactionParams contains params of CreateTransaction method.
+// It includes PayMe transaction id, creation time in milliseconds, amount and account.
+type CreateTransactionParams struct {
+	ID      string                 `json:"id"`
+	Time    int64                  `json:"time"`
+	Amount  int64                  `json:"amount"`
+	Account map[string]interface{} `json:"account"`
+}
+
+// CreateTransactionResult contains the result of CreateTransaction method.
+// It includes creation time, merchant transaction id and state.
+type CreateTransactionResult struct {
+	CreateTime  int64  `json:"create_time"`
+	Transaction string `json:"transaction"`
+	State       int    `json:"state"`
+}
+
+// PerformTransactionParams contains params of PerformTransaction method.
+// It includes PayMe transaction id.
+type PerformTransactionParams struct {
+	ID string `json:"id"`
+}
+
+// PerformTransactionResult contains the result of PerformTransaction method.
+// It includes merchant transaction id, perform time and state.
+type PerformTransactionResult struct {
+	Transaction string `json:"transaction"`
+	PerformTime int64  `json:"perform_time"`
+	State       int    `json:"state"`
+}
+
+// CancelTransactionParams contains params of CancelTransaction method.
+// It includes PayMe transaction id and the cancel reason code.
+type CancelTransactionParams struct {
+	ID     string `json:"id"`
+	Reason int    `json:"reason"`
+}
+
+// CancelTransactionResult contains the result of CancelTransaction method.
+// It includes merchant transaction id, cancel time and state.
+type CancelTransactionResult struct {
+	Transaction string `json:"transaction"`
+	CancelTime  int64  `json:"cancel_time"`
+	State       int    `json:"state"`
+}
+
+// CheckTransactionParams contains params of CheckTransaction method.
+// It includes PayMe transaction id.
+type CheckTransactionParams struct {
+	ID string `json:"id"`
+}
+
+// CheckTransactionResult contains the result of CheckTransaction method.
+// It includes all transaction timestamps in milliseconds, merchant transaction id, state and reason.
+type CheckTransactionResult struct {
+	CreateTime  int64  `json:"create_time"`
+	PerformTime int64  `json:"perform_time"`
+	CancelTime  int64  `json:"cancel_time"`
+	Transaction string `json:"transaction"`
+	State       int    `json:"state"`
+	Reason      *int   `json:"reason"`
+}
+
+// GetStatementParams contains params of GetStatement method.
+// It includes the time window in milliseconds.
+type GetStatementParams struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+}
+
+// StatementEntry represents a single transaction in GetStatement result.
+// It includes PayMe transaction id, amount, account, timestamps in milliseconds,
+// merchant transaction id, state and reason.
+type StatementEntry struct {
+	ID          string                 `json:"id"`
+	Time        int64                  `json:"time"`
+	Amount      int64                  `json:"amount"`
+	Account     map[string]interface{} `json:"account"`
+	CreateTime  int64                  `json:"create_time"`
+	PerformTime int64                  `json:"perform_time"`
+	CancelTime  int64                  `json:"cancel_time"`
+	Transaction string                 `json:"transaction"`
+	State       int                    `json:"state"`
+	Reason      *int                   `json:"reason"`
+}
+
+// GetStatementResult contains the result of GetStatement method.
+// It includes the list of transactions within the requested window.
+type GetStatementResult struct {
+	Transactions []StatementEntry `json:"transactions"`
+}
@@ -0,0 +1,47 @@
+package payment
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGetAllReceiptsResponseUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"bare array", `[{"_id":"r1","state":4},{"_id":"r2","state":0}]`, []string{"r1", "r2"}},
+		{"bare array with spaces", " \n [{\"_id\":\"r1\"}]", []string{"r1"}},
+		{"object", `{"receipts":[{"_id":"r3","state":4}]}`, []string{"r3"}},
+		{"empty array", `[]`, nil},
+		{"empty object", `{}`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp GetAllReceiptsResponse
+			if err := json.Unmarshal([]byte(tt.data), &resp); err != nil {
+				t.Fatalf("unmarshal error: %v", err)
+			}
+
+			if len(resp.Receipts) != len(tt.want) {
+				t.Fatalf("receipts = %d, want %d", len(resp.Receipts), len(tt.want))
+			}
+			for i, id := range tt.want {
+				if resp.Receipts[i].ID != id {
+					t.Errorf("receipt %d id = %s, want %s", i, resp.Receipts[i].ID, id)
+				}
+			}
+		})
+	}
+}
+
+func TestGetAllReceiptsResponseUnmarshalJSONInvalid(t *testing.T) {
+	for _, data := range []string{`"receipts"`, `[{"_id":1}]`, `{"receipts":{}}`} {
+		var resp GetAllReceiptsResponse
+		if err := json.Unmarshal([]byte(data), &resp); err == nil {
+			t.Errorf("unmarshal %s error = nil, want error", data)
+		}
+	}
+}
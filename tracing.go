@@ -0,0 +1,62 @@
+package payment
+
+import (
+	"context"
+	"strings"
+)
+
+// TracerName is the instrumentation name passed to TracerProvider.
+const TracerName = "payme.kisuke.uz"
+
+// TracerProvider creates tracers for PayMe API calls.
+// It mirrors the subset of OpenTelemetry API used by the client,
+// so an OpenTelemetry provider can be plugged in with a small adapter
+// without adding the dependency to this package.
+type TracerProvider interface {
+	Tracer(name string) Tracer
+}
+
+// Tracer starts spans for PayMe API calls.
+// The returned context must carry the span, so it nests under the caller's trace.
+type Tracer interface {
+	Start(ctx context.Context, spanName string) (context.Context, Span)
+}
+
+// Span is a single traced PayMe API call.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+// noopSpan is used when no TracerProvider is configured.
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, interface{}) {}
+func (noopSpan) RecordError(error)                {}
+func (noopSpan) End()                             {}
+
+// startSpan starts a span named "payme.<method>" if tracing is configured.
+// Returns the context with the span and the span, or a no-op span.
+func (c *Client) startSpan(ctx context.Context, method string) (context.Context, Span) {
+	if c.Tracer == nil {
+		return ctx, noopSpan{}
+	}
+	return c.Tracer.Start(ctx, "payme."+method)
+}
+
+// receiptIDFromParams extracts the receipt id from receipts.* method params.
+// Returns an empty string if params has no receipt id.
+func receiptIDFromParams(method string, params interface{}) string {
+	if !strings.HasPrefix(method, "receipts.") {
+		return ""
+	}
+
+	paramsMap, ok := params.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	receiptID, _ := paramsMap["id"].(string)
+	return receiptID
+}
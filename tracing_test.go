@@ -0,0 +1,112 @@
+package payment
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordedSpan is a span recorded by recordingTracer.
+type recordedSpan struct {
+	name       string
+	attributes map[string]interface{}
+	errs       []error
+	ended      bool
+}
+
+// recordingTracer is a TracerProvider and Tracer recording all started spans.
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (t *recordingTracer) Tracer(string) Tracer {
+	return t
+}
+
+func (t *recordingTracer) Start(ctx context.Context, spanName string) (context.Context, Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	span := &recordedSpan{name: spanName, attributes: make(map[string]interface{})}
+	t.spans = append(t.spans, span)
+	return ctx, &recordingSpan{tracer: t, span: span}
+}
+
+// recordingSpan records span calls under the tracer lock.
+type recordingSpan struct {
+	tracer *recordingTracer
+	span   *recordedSpan
+}
+
+func (s *recordingSpan) SetAttribute(key string, value interface{}) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+
+	s.span.attributes[key] = value
+}
+
+func (s *recordingSpan) RecordError(err error) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+
+	s.span.errs = append(s.span.errs, err)
+}
+
+func (s *recordingSpan) End() {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+
+	s.span.ended = true
+}
+
+func TestSendRequestSpanAttributes(t *testing.T) {
+	server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+		if call.Method == "receipts.get" {
+			return nil, &Error{Code: ReceiptNotFoundErrorCode, Message: "receipt not found"}
+		}
+		return receiptResult(Receipt{ID: "receipt-1", State: int(StatePaid)}), nil
+	})
+	tracer := &recordingTracer{}
+	client := newTestClient(t, server.URL, func(config *ClientConfig) {
+		config.TracerProvider = tracer
+	})
+
+	if _, err := client.CheckReceipt(context.Background(), "receipt-1"); err != nil {
+		t.Fatalf("CheckReceipt error: %v", err)
+	}
+	if _, err := client.GetReceipt(context.Background(), "receipt-2"); err == nil {
+		t.Fatal("GetReceipt error = nil, want PayMe error")
+	}
+
+	if len(tracer.spans) != 2 {
+		t.Fatalf("spans = %d, want 2", len(tracer.spans))
+	}
+
+	check := tracer.spans[0]
+	if check.name != "payme.receipts.check" || !check.ended {
+		t.Errorf("span = %s ended %v, want ended payme.receipts.check", check.name, check.ended)
+	}
+	want := map[string]interface{}{
+		"payme.method":     "receipts.check",
+		"payme.receipt_id": "receipt-1",
+		"http.status_code": 200,
+	}
+	for key, value := range want {
+		if check.attributes[key] != value {
+			t.Errorf("attribute %s = %v, want %v", key, check.attributes[key], value)
+		}
+	}
+	if requestID, _ := check.attributes["payme.request_id"].(string); !strings.HasPrefix(requestID, "ReceiptsCheck:") {
+		t.Errorf("attribute payme.request_id = %q, want ReceiptsCheck request id", requestID)
+	}
+
+	get := tracer.spans[1]
+	if get.attributes["payme.receipt_id"] != "receipt-2" || get.attributes["payme.error_code"] != ReceiptNotFoundErrorCode {
+		t.Errorf("attributes = %v, want receipt-2 and error code", get.attributes)
+	}
+	if len(get.errs) != 1 {
+		t.Errorf("recorded errors = %d, want 1", len(get.errs))
+	}
+}
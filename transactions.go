@@ -0,0 +1,152 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ===== TRANSACTION STATES =====
+
+// TransactionState represents the state of a transaction in PayMe system.
+type TransactionState int
+
+const (
+	TransactionStateCreated               TransactionState = 1
+	TransactionStateCompleted             TransactionState = 2
+	TransactionStateCanceled              TransactionState = -1
+	TransactionStateCanceledAfterComplete TransactionState = -2
+)
+
+// String returns the human-readable name of the transaction state.
+func (s TransactionState) String() string {
+	switch s {
+	case TransactionStateCreated:
+		return "created"
+	case TransactionStateCompleted:
+		return "completed"
+	case TransactionStateCanceled:
+		return "canceled"
+	case TransactionStateCanceledAfterComplete:
+		return "canceled after complete"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// IsCompleted checks if the transaction is completed.
+func (s TransactionState) IsCompleted() bool {
+	return s == TransactionStateCompleted
+}
+
+// IsCanceled checks if the transaction is canceled before or after completion.
+func (s TransactionState) IsCanceled() bool {
+	return s == TransactionStateCanceled || s == TransactionStateCanceledAfterComplete
+}
+
+// ===== TRANSACTION CANCEL REASON =====
+
+// CancelReason represents the reason of a transaction cancel like CancelReasonRefund.
+type CancelReason int
+
+// String returns the human-readable name of the cancel reason.
+func (r CancelReason) String() string {
+	switch r {
+	case CancelReasonReceiverNotFound:
+		return "receiver not found"
+	case CancelReasonDebitError:
+		return "debit error"
+	case CancelReasonTransactionError:
+		return "transaction error"
+	case CancelReasonTimeout:
+		return "timeout"
+	case CancelReasonRefund:
+		return "refund"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(r))
+	}
+}
+
+// Status returns the typed state of the transaction.
+// It is named Status because Transaction already has the State field.
+func (t *Transaction) Status() TransactionState {
+	return TransactionState(t.State)
+}
+
+// IsCompleted checks if the transaction is completed.
+func (t *Transaction) IsCompleted() bool {
+	return t.Status().IsCompleted()
+}
+
+// IsCanceled checks if the transaction is canceled before or after completion.
+func (t *Transaction) IsCanceled() bool {
+	return t.Status().IsCanceled()
+}
+
+// CancelReason returns the typed cancel reason of the transaction.
+// Returns the reason and false if the transaction has no reason.
+func (t *Transaction) CancelReason() (CancelReason, bool) {
+	if t.Reason == nil {
+		return 0, false
+	}
+	return CancelReason(*t.Reason), true
+}
+
+// GetAllTransactions retrieves multiple transactions within a specified time range.
+// It sends a request to transactions.get_all method with time parameters.
+// Returns GetAllTransactionsResponse with transaction list or an error.
+func (c *Client) GetAllTransactions(ctx context.Context, from, to int64, count int, opts ...RequestOption) (*GetAllTransactionsResponse, error) {
+	requestID := c.newRequestID("TransactionsGetAll")
+
+	transactionParams := map[string]interface{}{
+		"from":  from,
+		"to":    to,
+		"count": count,
+	}
+
+	resp, err := c.sendRequest(ctx, requestID, "transactions.get_all", transactionParams, false, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse result
+	var result GetAllTransactionsResponse
+	if resp.Result != nil {
+		resultBytes, _ := json.Marshal(resp.Result)
+		if err := json.Unmarshal(resultBytes, &result); err != nil {
+			return nil, fmt.Errorf("result unmarshal error: %w", err)
+		}
+	}
+
+	return &result, nil
+}
+
+// GetTransactionsByDateRange retrieves transactions within a specific date range.
+// It converts time.Time to Unix timestamp and calls GetAllTransactions.
+// Returns GetAllTransactionsResponse with transactions in the specified range.
+func (c *Client) GetTransactionsByDateRange(ctx context.Context, from, to time.Time, limit int) (*GetAllTransactionsResponse, error) {
+	return c.GetAllTransactions(ctx, from.UnixMilli(), to.UnixMilli(), limit)
+}
+
+// GetTransactionsByState retrieves transactions within the time range filtered by their state.
+// Since PayMe API doesn't directly support state filtering, it pages through all transactions
+// with IterateTransactions and filters them on the client side.
+// Returns GetAllTransactionsResponse with filtered transactions.
+func (c *Client) GetTransactionsByState(ctx context.Context, state int, from, to time.Time, pageSize int) (*GetAllTransactionsResponse, error) {
+	var filteredTransactions []*Transaction
+
+	it := c.IterateTransactions(ctx, from, to, pageSize)
+	for transaction, ok := it.Next(); ok; transaction, ok = it.Next() {
+		if transaction.State == state {
+			filteredTransactions = append(filteredTransactions, transaction)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	return &GetAllTransactionsResponse{
+		Transactions: filteredTransactions,
+	}, nil
+}
@@ -0,0 +1,54 @@
+package payment
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGetTransactionsByStatePagesThroughRange(t *testing.T) {
+	// A year old transactions, more than one page
+	base := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC).UnixMilli()
+	stored := []*Transaction{
+		{ID: "t1", CreateTime: base, State: 2},
+		{ID: "t2", CreateTime: base + 1000, State: 1},
+		{ID: "t3", CreateTime: base + 2000, State: 2},
+		{ID: "t4", CreateTime: base + 2000, State: -1},
+		{ID: "t5", CreateTime: base + 3000, State: 2},
+	}
+
+	pages := 0
+	server := newRPCServer(t, func(call rpcCall) (interface{}, *Error) {
+		pages++
+		from := int64(call.Params["from"].(float64))
+		count := int(call.Params["count"].(float64))
+
+		var page []*Transaction
+		for _, transaction := range stored {
+			if transaction.CreateTime >= from && len(page) < count {
+				page = append(page, transaction)
+			}
+		}
+		return map[string]interface{}{"transactions": page}, nil
+	})
+	client := newTestClient(t, server.URL)
+
+	from := time.UnixMilli(base)
+	to := time.UnixMilli(base + 10000)
+
+	resp, err := client.GetTransactionsByState(context.Background(), 2, from, to, 3)
+	if err != nil {
+		t.Fatalf("GetTransactionsByState error: %v", err)
+	}
+
+	var ids []string
+	for _, transaction := range resp.Transactions {
+		ids = append(ids, transaction.ID)
+	}
+	if len(ids) != 3 || ids[0] != "t1" || ids[1] != "t3" || ids[2] != "t5" {
+		t.Errorf("transactions = %v, want [t1 t3 t5]", ids)
+	}
+	if pages < 3 {
+		t.Errorf("pages = %d, want all pages fetched", pages)
+	}
+}
@@ -0,0 +1,70 @@
+package payment
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMaxIdleConns        = 100
+	defaultMaxIdleConnsPerHost = 100
+	defaultIdleConnTimeout     = 90 * time.Second
+)
+
+// newTransport creates an HTTP transport tuned for many requests to a single PayMe host.
+// It keeps more idle connections per host than http.DefaultTransport to avoid TCP and TLS handshakes.
+// Returns a pointer to http.Transport.
+func newTransport(config ClientConfig) *http.Transport {
+	if config.MaxIdleConns == 0 {
+		config.MaxIdleConns = defaultMaxIdleConns
+	}
+	if config.MaxIdleConnsPerHost == 0 {
+		config.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+	if config.IdleConnTimeout == 0 {
+		config.IdleConnTimeout = defaultIdleConnTimeout
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = config.MaxIdleConns
+	transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	transport.IdleConnTimeout = config.IdleConnTimeout
+
+	if config.TLSConfig != nil {
+		transport.TLSClientConfig = config.TLSConfig.Clone()
+	}
+
+	return transport
+}
+
+// WithCertPinning creates a TLS config accepting only certificates with the given SHA-256 fingerprints.
+// Fingerprints are hex encoded, colons and case are ignored. The chain is still verified by system CAs,
+// and the connection is accepted if any certificate of the chain is pinned.
+// It only applies when the client builds its own transport, i.e. ClientConfig.HTTPClient.Transport is nil.
+// Returns a pointer to tls.Config to use as ClientConfig.TLSConfig.
+func WithCertPinning(sha256Fingerprints ...string) *tls.Config {
+	pins := make(map[string]struct{}, len(sha256Fingerprints))
+	for _, fingerprint := range sha256Fingerprints {
+		fingerprint = strings.ToLower(strings.ReplaceAll(fingerprint, ":", ""))
+		pins[fingerprint] = struct{}{}
+	}
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			for _, rawCert := range rawCerts {
+				sum := sha256.Sum256(rawCert)
+				if _, ok := pins[hex.EncodeToString(sum[:])]; ok {
+					return nil
+				}
+			}
+			return fmt.Errorf("%w: no certificate of the chain matches pinned fingerprints", ErrCertificateNotPinned)
+		},
+	}
+}
@@ -3,44 +3,163 @@ package payment
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
-var (
+const (
 	TestEndpoint       = "https://checkout.test.paycom.uz/api"
 	ProductionEndpoint = "https://checkout.paycom.uz/api"
 )
 
+// EndpointFor returns the Subscribe API endpoint for the environment.
+// Returns TestEndpoint in test mode, ProductionEndpoint otherwise.
+func EndpointFor(testMode bool) string {
+	if testMode {
+		return TestEndpoint
+	}
+	return ProductionEndpoint
+}
+
+// Version is the version of the library sent in the default User-Agent header.
+const Version = "1.0.0"
+
+// MaxAmount is the maximum receipt amount in tiyin accepted by PayMe.
+const MaxAmount = 999999999999
+
 // ===== PAYME CURRENCY CODES =====
 
 const (
 	CurrencyUZS = 860 // Uzbekistan Som
 	CurrencyUSD = 840 // US Dollar
 	CurrencyEUR = 978 // Euro
+	CurrencyRUB = 643 // Russian Ruble
+	CurrencyKZT = 398 // Kazakhstani Tenge
+	CurrencyGBP = 826 // Pound Sterling
 )
 
+// CurrencyInfo contains display metadata of a currency.
+type CurrencyInfo struct {
+	// ISO 4217 numeric code
+	Code int
+	// ISO 4217 alpha code like UZS
+	Alpha string
+	// display symbol
+	Symbol string
+	// number of minor unit digits, e.g. 2 for tiyin and cents
+	Decimals int
+	// currency name
+	Name string
+}
+
+// currencies contains metadata of the supported currencies by code.
+var currencies = map[int]CurrencyInfo{
+	CurrencyUZS: {Code: CurrencyUZS, Alpha: "UZS", Symbol: "сум", Decimals: 2, Name: "Uzbekistan Som"},
+	CurrencyUSD: {Code: CurrencyUSD, Alpha: "USD", Symbol: "$", Decimals: 2, Name: "US Dollar"},
+	CurrencyEUR: {Code: CurrencyEUR, Alpha: "EUR", Symbol: "€", Decimals: 2, Name: "Euro"},
+	CurrencyRUB: {Code: CurrencyRUB, Alpha: "RUB", Symbol: "₽", Decimals: 2, Name: "Russian Ruble"},
+	CurrencyKZT: {Code: CurrencyKZT, Alpha: "KZT", Symbol: "₸", Decimals: 2, Name: "Kazakhstani Tenge"},
+	CurrencyGBP: {Code: CurrencyGBP, Alpha: "GBP", Symbol: "£", Decimals: 2, Name: "Pound Sterling"},
+}
+
+// GetCurrencyInfo returns the metadata of the currency.
+// Returns CurrencyInfo and false if the currency is not supported.
+func GetCurrencyInfo(code int) (CurrencyInfo, bool) {
+	info, ok := currencies[code]
+	return info, ok
+}
+
+// CurrencyName returns the name of the currency like "US Dollar".
+// Returns an empty string if the currency is not supported.
+func CurrencyName(code int) string {
+	return currencies[code].Name
+}
+
+// CurrencyAlpha returns the ISO 4217 alpha code of the currency like "USD".
+// Returns an empty string if the currency is not supported.
+func CurrencyAlpha(code int) string {
+	return currencies[code].Alpha
+}
+
+// CurrencyCodeFromAlpha returns the ISO 4217 numeric code of the alpha code like "usd", case-insensitive.
+// Returns the code and false if the currency is not supported.
+func CurrencyCodeFromAlpha(alpha string) (int, bool) {
+	alpha = strings.ToUpper(strings.TrimSpace(alpha))
+	for code, info := range currencies {
+		if info.Alpha == alpha {
+			return code, true
+		}
+	}
+	return 0, false
+}
+
+// currencyDecimals returns the number of minor unit digits of the currency.
+// Returns the UZS decimals if the currency is not supported.
+func currencyDecimals(currency int) int {
+	if info, ok := currencies[currency]; ok {
+		return info.Decimals
+	}
+	return currencies[CurrencyUZS].Decimals
+}
+
+// minorUnits returns the number of minor units in a major unit of the currency.
+// Returns 100 for UZS if the currency is not supported.
+func minorUnits(currency int) int64 {
+	decimals := currencyDecimals(currency)
+
+	units := int64(1)
+	for i := 0; i < decimals; i++ {
+		units *= 10
+	}
+	return units
+}
+
 // SomToTiyin converts Uzbek som to tiyin (smallest currency unit).
-// PayMe API expects amounts in tiyin, not som.
+// PayMe API expects amounts in tiyin, not som. The result is rounded to the
+// nearest tiyin, use MoneyFromSom to avoid float math completely.
 // Returns the amount in tiyin as int64.
 func SomToTiyin(som float64) int64 {
-	return int64(som * 100)
+	return int64(math.Round(som * 100))
 }
 
 // TiyinToSom converts tiyin (smallest currency unit) to Uzbek som.
 // Useful for displaying amounts in human-readable format.
 // Returns the amount in som as float64.
 func TiyinToSom(tiyin int64) float64 {
-	return float64(tiyin) / 100
+	return TiyinToSomIn(tiyin, CurrencyUZS)
 }
 
-func FromSomToTiyin(amount int) int {
-	return amount * 100
+// TiyinToSomIn converts minor units to major units of the currency using its CurrencyInfo decimals.
+// UZS decimals are used if the currency is not supported.
+// Returns the amount in major units as float64.
+func TiyinToSomIn(amount int64, currency int) float64 {
+	return float64(amount) / float64(minorUnits(currency))
 }
 
-func FromTiyinToSom(amount int) int {
+// FromSomToTiyin converts whole Uzbek som to tiyin.
+// Returns the amount in tiyin as int64.
+func FromSomToTiyin(amount int64) int64 {
+	return FromSomToTiyinIn(amount, CurrencyUZS)
+}
+
+// FromSomToTiyinIn converts whole major units of the currency to minor units using its CurrencyInfo decimals.
+// UZS decimals are used if the currency is not supported.
+// Returns the amount in minor units as int64.
+func FromSomToTiyinIn(amount int64, currency int) int64 {
+	return amount * minorUnits(currency)
+}
+
+// FromTiyinToSom converts tiyin to whole Uzbek som, dropping the fraction.
+// Returns the amount in som as int64.
+func FromTiyinToSom(amount int64) int64 {
 	return amount / 100
 }
 
+// GetCurrentTimestamp returns the current system time in milliseconds.
+// Use Client.CurrentTimestamp to get the time of the client clock.
 func GetCurrentTimestamp() int64 {
 	return time.Now().UnixMilli()
 }
@@ -60,66 +179,270 @@ func ParseTimestamp(timestampStr string) (int64, error) {
 	return t.UnixMilli(), nil
 }
 
+// ValidateAmount validates the amount in tiyin.
+// Returns *ValidationError matching ErrInvalidAmount if the amount is not positive or exceeds MaxAmount.
 func ValidateAmount(amount int64) error {
+	value := strconv.FormatInt(amount, 10)
 	if amount <= 0 {
-		return ErrInvalidAmount
+		return &ValidationError{Field: "amount", Value: value, Rule: "must be positive", Err: ErrInvalidAmount}
 	}
-	if amount > 999999999999 {
-		return ErrInvalidAmount
+	if amount > MaxAmount {
+		return &ValidationError{Field: "amount", Value: value, Rule: fmt.Sprintf("must not exceed %d", MaxAmount), Err: ErrInvalidAmount}
 	}
 	return nil
 }
 
+// ValidateCardToken validates the card token length.
+// The token is masked in the returned error.
+// Returns *ValidationError matching ErrInvalidFormatToken if the token is empty or has invalid length.
 func ValidateCardToken(token string) error {
 	if token == "" {
-		return ErrInvalidFormatToken
+		return &ValidationError{Field: "token", Rule: "must not be empty", Err: ErrInvalidFormatToken}
 	}
 	if len(token) < 10 || len(token) > 100 {
-		return ErrInvalidFormatToken
+		return &ValidationError{Field: "token", Value: MaskCardNumber(token), Rule: "length must be between 10 and 100", Err: ErrInvalidFormatToken}
 	}
 	return nil
 }
 
+// ValidateReceiptID validates the receipt ID length.
+// Returns *ValidationError matching ErrReceiptNotFound if the ID is empty or has invalid length.
 func ValidateReceiptID(id string) error {
 	if id == "" {
-		return ErrReceiptNotFound
+		return &ValidationError{Field: "receipt_id", Rule: "must not be empty", Err: ErrReceiptNotFound}
 	}
 	if len(id) < 5 || len(id) > 100 {
-		return ErrReceiptNotFound
+		return &ValidationError{Field: "receipt_id", Value: TruncateString(id, 30), Rule: "length must be between 5 and 100", Err: ErrReceiptNotFound}
+	}
+	return nil
+}
+
+// ValidateUzPhone validates an Uzbek phone number in 998XXXXXXXXX format.
+// A leading "+" is allowed.
+// Returns ErrInvalidParams if the phone is invalid.
+func ValidateUzPhone(phone string) error {
+	digits := strings.TrimPrefix(phone, "+")
+	if len(digits) != 12 || !strings.HasPrefix(digits, "998") {
+		return fmt.Errorf("phone %q must be in 998XXXXXXXXX format: %w", phone, ErrInvalidParams)
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return fmt.Errorf("phone %q must contain only digits: %w", phone, ErrInvalidParams)
+		}
+	}
+	return nil
+}
+
+// ===== CARD TYPES =====
+
+const (
+	CardTypeUzcard     = "uzcard"
+	CardTypeHumo       = "humo"
+	CardTypeVisa       = "visa"
+	CardTypeMastercard = "mastercard"
+)
+
+// ValidateCardNumber validates a raw card number with the Luhn algorithm.
+// The number must contain 12 to 19 digits without spaces.
+// Returns ErrInvalidParams if the number is invalid.
+func ValidateCardNumber(number string) error {
+	if !isValidLuhn(number) {
+		return ErrInvalidParams
+	}
+	return nil
+}
+
+// DetectCardType detects the card type by its BIN prefix.
+// UzCard starts with 8600 or 5614, Humo with 9860, Visa with 4,
+// and Mastercard with 51-55 or 2221-2720.
+// Returns the card type or an empty string if unknown.
+func DetectCardType(number string) string {
+	if len(number) < 4 {
+		return ""
+	}
+
+	prefix, err := strconv.Atoi(number[:4])
+	if err != nil {
+		return ""
 	}
+
+	switch {
+	case prefix == 8600 || prefix == 5614:
+		return CardTypeUzcard
+	case prefix == 9860:
+		return CardTypeHumo
+	case number[0] == '4':
+		return CardTypeVisa
+	case prefix >= 5100 && prefix <= 5599, prefix >= 2221 && prefix <= 2720:
+		return CardTypeMastercard
+	default:
+		return ""
+	}
+}
+
+// isValidLuhn checks the card number against the Luhn algorithm.
+// Returns true if number contains only digits and the checksum is valid.
+func isValidLuhn(number string) bool {
+	if len(number) < 12 || len(number) > 19 {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		if number[i] < '0' || number[i] > '9' {
+			return false
+		}
+		digit := int(number[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+
+	return sum%10 == 0
+}
+
+// ParseCardExpiry parses the card expire date in "MMYY" or "MM/YY" format.
+// Returns month (1-12), four-digit year, or ErrInvalidParams if the format is invalid.
+func ParseCardExpiry(expire string) (month, year int, err error) {
+	digits := expire
+	if len(expire) == 5 {
+		if expire[2] != '/' {
+			return 0, 0, ErrInvalidParams
+		}
+		digits = expire[:2] + expire[3:]
+	}
+
+	if len(digits) != 4 {
+		return 0, 0, ErrInvalidParams
+	}
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return 0, 0, ErrInvalidParams
+		}
+	}
+
+	month = int(digits[0]-'0')*10 + int(digits[1]-'0')
+	year = 2000 + int(digits[2]-'0')*10 + int(digits[3]-'0')
+
+	if month < 1 || month > 12 {
+		return 0, 0, ErrInvalidParams
+	}
+
+	return month, year, nil
+}
+
+// ValidateCardExpiry validates the card expire date in "MMYY" or "MM/YY" format.
+// The card is valid until the end of the expire month.
+// Returns an error wrapping ErrInvalidParams for invalid format or ErrCardExpired for past dates.
+func ValidateCardExpiry(expire string) error {
+	return validateCardExpiryAt(expire, time.Now())
+}
+
+// validateCardExpiryAt validates the card expire date at the given time.
+// Returns an error wrapping ErrInvalidParams for invalid format or ErrCardExpired for past dates.
+func validateCardExpiryAt(expire string, now time.Time) error {
+	month, year, err := ParseCardExpiry(expire)
+	if err != nil {
+		return fmt.Errorf("card expire must be in MMYY or MM/YY format: %w", err)
+	}
+
+	// First moment of the month after expiry
+	expiresAt := time.Date(year, time.Month(month)+1, 1, 0, 0, 0, 0, time.UTC)
+	if !now.Before(expiresAt) {
+		return ErrCardExpired
+	}
+
 	return nil
 }
 
+// IsCardExpired checks if the card expire date is in the past.
+// Returns true if expired or the format is invalid.
+func IsCardExpired(expire string) bool {
+	return ValidateCardExpiry(expire) != nil
+}
+
+// isValidVerifyCode checks if the SMS verification code has 4 to 6 digits.
+// Returns true if code is numeric and has valid length.
+func isValidVerifyCode(code string) bool {
+	if len(code) < 4 || len(code) > 6 {
+		return false
+	}
+	for i := 0; i < len(code); i++ {
+		if code[i] < '0' || code[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// requestIDCounter makes request ids unique when the clock returns the same time for several calls.
+var requestIDCounter atomic.Uint64
+
 // GenerateRequestID creates a unique request identifier for PayMe API calls.
-// It combines a prefix with a UUID to ensure uniqueness across requests.
-// Returns a string in format "prefix-uuid".
+// It combines a prefix with the current time in nanoseconds and a process-wide counter,
+// so ids are unique across goroutines even on platforms with a coarse clock.
+// Returns a string in format "prefix:nanos-counter".
 func GenerateRequestID(prefix string) string {
-	return fmt.Sprintf("%s:%d", prefix, time.Now().UnixNano())
+	return GenerateRequestIDAt(prefix, time.Now())
+}
+
+// GenerateRequestIDAt creates a unique request identifier with the prefix at the given time.
+// The counter is shared by the whole process, so ids depend on earlier calls and are not
+// reproducible between runs. Client request ids use a counter of the client instead.
+// Returns a string in format "prefix:nanos-counter".
+func GenerateRequestIDAt(prefix string, t time.Time) string {
+	return formatRequestID(prefix, t, requestIDCounter.Add(1))
+}
+
+// formatRequestID formats a request id from the prefix, time and counter value.
+func formatRequestID(prefix string, t time.Time, seq uint64) string {
+	return fmt.Sprintf("%s:%d-%d", prefix, t.UnixNano(), seq)
 }
 
+// GenerateReceiptID creates a receipt identifier for the charge at the current system time.
+// Use Client.GenerateReceiptID to create it at the time of the client clock.
+// Returns a string in format "receipt_chargeID_seconds".
 func GenerateReceiptID(chargeID string) string {
-	return fmt.Sprintf("receipt_%s_%d", chargeID, time.Now().Unix())
+	return GenerateReceiptIDAt(chargeID, time.Now())
+}
+
+// GenerateReceiptIDAt creates a receipt identifier for the charge at the given time.
+// Returns a string in format "receipt_chargeID_seconds".
+func GenerateReceiptIDAt(chargeID string, t time.Time) string {
+	return fmt.Sprintf("receipt_%s_%d", chargeID, t.Unix())
 }
 
 // IsValidCurrency validates if the provided currency code is supported.
-// Currently supports UZS (860), USD (840), and EUR (978).
+// Supported currencies are listed in the CurrencyInfo table.
 // Returns true if currency is valid, false otherwise.
 func IsValidCurrency(currency int) bool {
-	validCurrencies := []int{
-		CurrencyUZS, CurrencyUSD, CurrencyEUR,
-	}
-	for _, valid := range validCurrencies {
-		if valid == currency {
+	_, ok := currencies[currency]
+	return ok
+}
+
+func IsValidReceiptState(state int) bool {
+	validStates := []ReceiptState{StateCreated, StatePaid, StateCanceled, StateExpired}
+	for _, valid := range validStates {
+		if valid == ReceiptState(state) {
 			return true
 		}
 	}
 	return false
 }
 
-func IsValidReceiptState(state int) bool {
-	validStates := []int{0, 1, -1, -2} // Created, Paid, Canceled, Expired
-	for _, valid := range validStates {
-		if valid == state {
+func IsValidCancelReason(reason int) bool {
+	validReasons := []int{
+		CancelReasonReceiverNotFound, CancelReasonDebitError, CancelReasonTransactionError,
+		CancelReasonTimeout, CancelReasonRefund,
+	}
+	for _, valid := range validReasons {
+		if valid == reason {
 			return true
 		}
 	}
@@ -155,17 +478,14 @@ func MaskCardNumber(number string) string {
 }
 
 // FormatAmount formats a monetary amount with appropriate currency symbol.
-// Converts tiyin to som for UZS, and formats with currency symbols.
-// Returns a formatted string like "100.00 сум" or "$10.00".
+// Converts minor units with the currency decimals from CurrencyInfo, UZS is used for unknown currencies.
+// Returns a formatted string like "100.00 сум" or "10.00 $".
 func FormatAmount(amount int64, currency int) string {
-	currencySymbol := "сум"
-	switch currency {
-	case CurrencyUSD:
-		currencySymbol = "$"
-	case CurrencyEUR:
-		currencySymbol = "€"
+	info, ok := GetCurrencyInfo(currency)
+	if !ok {
+		info, currency = currencies[CurrencyUZS], CurrencyUZS
 	}
 
-	formattedAmount := fmt.Sprintf("%.2f", TiyinToSom(amount))
-	return formattedAmount + " " + currencySymbol
+	formattedAmount := fmt.Sprintf("%.*f", info.Decimals, TiyinToSomIn(amount, currency))
+	return formattedAmount + " " + info.Symbol
 }
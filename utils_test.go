@@ -0,0 +1,261 @@
+package payment
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSomTiyinConversionBeyondInt32(t *testing.T) {
+	som := int64(math.MaxInt32) + 1
+
+	tiyin := FromSomToTiyin(som)
+	if tiyin != 214748364800 {
+		t.Errorf("FromSomToTiyin(%d) = %d, want 214748364800", som, tiyin)
+	}
+	if got := FromTiyinToSom(tiyin + 99); got != som {
+		t.Errorf("FromTiyinToSom(%d) = %d, want %d", tiyin+99, got, som)
+	}
+	if err := ValidateAmount(FromSomToTiyin(MaxAmount / 100)); err != nil {
+		t.Errorf("ValidateAmount(max) error: %v", err)
+	}
+	if err := ValidateAmount(MaxAmount + 1); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("ValidateAmount(max + 1) error = %v, want ErrInvalidAmount", err)
+	}
+}
+
+func TestCurrencyConversions(t *testing.T) {
+	tests := []struct {
+		currency int
+		minor    int64
+		major    float64
+	}{
+		{CurrencyUZS, 150050, 1500.5},
+		{CurrencyUSD, 1999, 19.99},
+		{999, 1999, 19.99}, // unsupported currency uses UZS decimals
+	}
+
+	for _, tt := range tests {
+		if got := TiyinToSomIn(tt.minor, tt.currency); got != tt.major {
+			t.Errorf("TiyinToSomIn(%d, %d) = %v, want %v", tt.minor, tt.currency, got, tt.major)
+		}
+		if got := FromSomToTiyinIn(int64(tt.major), tt.currency); got != int64(tt.major)*100 {
+			t.Errorf("FromSomToTiyinIn(%d, %d) = %d, want %d", int64(tt.major), tt.currency, got, int64(tt.major)*100)
+		}
+	}
+
+	if got := TiyinToSom(150050); got != 1500.5 {
+		t.Errorf("TiyinToSom(150050) = %v, want 1500.5", got)
+	}
+}
+
+func TestValidateCardNumber(t *testing.T) {
+	tests := []struct {
+		number string
+		valid  bool
+	}{
+		{"8600069195406311", true},
+		{"8600495473316478", true},
+		{"9860080323894719", true},
+		{"4111111111111111", true},
+		{"8600069195406312", false}, // wrong check digit
+		{"8600 0691 9540 6311", false},
+		{"86000691954", false}, // too short
+		{"86000691954063110000", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		err := ValidateCardNumber(tt.number)
+		if tt.valid && err != nil {
+			t.Errorf("ValidateCardNumber(%q) error: %v", tt.number, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidParams) {
+			t.Errorf("ValidateCardNumber(%q) error = %v, want ErrInvalidParams", tt.number, err)
+		}
+	}
+}
+
+func TestDetectCardType(t *testing.T) {
+	tests := []struct {
+		number string
+		want   string
+	}{
+		{"8600069195406311", CardTypeUzcard},
+		{"5614681005807856", CardTypeUzcard},
+		{"9860080323894719", CardTypeHumo},
+		{"4111111111111111", CardTypeVisa},
+		{"5555555555554444", CardTypeMastercard},
+		{"2221000000000009", CardTypeMastercard},
+		{"6200000000000000", ""},
+		{"860", ""},
+		{"86a0069195406311", ""},
+	}
+
+	for _, tt := range tests {
+		if got := DetectCardType(tt.number); got != tt.want {
+			t.Errorf("DetectCardType(%q) = %q, want %q", tt.number, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateRequestIDUniqueAcrossGoroutines(t *testing.T) {
+	const goroutines, perGoroutine = 16, 500
+
+	var (
+		mu  sync.Mutex
+		ids = make(map[string]struct{}, goroutines*perGoroutine)
+		wg  sync.WaitGroup
+	)
+	at := time.Unix(1700000000, 0)
+
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perGoroutine; j++ {
+				// Same time for every call, only the counter differs
+				id := GenerateRequestIDAt("test", at)
+				mu.Lock()
+				ids[id] = struct{}{}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(ids) != goroutines*perGoroutine {
+		t.Errorf("unique ids = %d, want %d", len(ids), goroutines*perGoroutine)
+	}
+	for id := range ids {
+		if !strings.HasPrefix(id, "test:1700000000000000000-") {
+			t.Fatalf("id = %q, want prefix and time", id)
+		}
+	}
+}
+
+func TestClientRequestIDsReproducibleWithFixedClock(t *testing.T) {
+	newIDs := func() []string {
+		client := newTestClient(t, "http://127.0.0.1", func(config *ClientConfig) {
+			config.Clock = fixedClock(time.Unix(1700000000, 0))
+		})
+		return []string{client.newRequestID("receipts.create"), client.newRequestID("receipts.pay")}
+	}
+
+	// Package ids move the global counter between clients
+	GenerateRequestID("other")
+
+	first, second := newIDs(), newIDs()
+	want := []string{"receipts.create:1700000000000000000-1", "receipts.pay:1700000000000000000-2"}
+	for i := range want {
+		if first[i] != want[i] || second[i] != want[i] {
+			t.Errorf("id %d = %q and %q, want %q", i, first[i], second[i], want[i])
+		}
+	}
+}
+
+func TestAddedCurrencies(t *testing.T) {
+	tests := []struct {
+		code      int
+		name      string
+		formatted string
+	}{
+		{CurrencyRUB, "Russian Ruble", "123.45 ₽"},
+		{CurrencyKZT, "Kazakhstani Tenge", "123.45 ₸"},
+		{CurrencyGBP, "Pound Sterling", "123.45 £"},
+	}
+
+	for _, tt := range tests {
+		if !IsValidCurrency(tt.code) {
+			t.Errorf("IsValidCurrency(%d) = false, want true", tt.code)
+		}
+		if got := CurrencyName(tt.code); got != tt.name {
+			t.Errorf("CurrencyName(%d) = %q, want %q", tt.code, got, tt.name)
+		}
+		if got := FormatAmount(12345, tt.code); got != tt.formatted {
+			t.Errorf("FormatAmount(12345, %d) = %q, want %q", tt.code, got, tt.formatted)
+		}
+	}
+
+	if IsValidCurrency(392) {
+		t.Error("IsValidCurrency(392) = true, want false for unsupported JPY")
+	}
+	if got := FormatAmount(12345, 392); got != "123.45 сум" {
+		t.Errorf("FormatAmount(12345, 392) = %q, want UZS fallback", got)
+	}
+}
+
+func TestValidatorsReturnValidationError(t *testing.T) {
+	longToken := "8600069195406311" + strings.Repeat("0", 100)
+
+	tests := []struct {
+		name     string
+		err      error
+		field    string
+		value    string
+		sentinel error
+	}{
+		{"negative amount", ValidateAmount(-5), "amount", "-5", ErrInvalidAmount},
+		{"amount over max", ValidateAmount(MaxAmount + 1), "amount", "1000000000000", ErrInvalidAmount},
+		{"empty token", ValidateCardToken(""), "token", "", ErrInvalidFormatToken},
+		{"long token", ValidateCardToken(longToken), "token", "8600****0000", ErrInvalidFormatToken},
+		{"empty receipt id", ValidateReceiptID(""), "receipt_id", "", ErrReceiptNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var validationErr *ValidationError
+			if !errors.As(tt.err, &validationErr) {
+				t.Fatalf("error = %v, want ValidationError", tt.err)
+			}
+			if validationErr.Field != tt.field || validationErr.Value != tt.value || validationErr.Rule == "" {
+				t.Errorf("error = %+v, want field %s value %q and a rule", validationErr, tt.field, tt.value)
+			}
+			if !errors.Is(tt.err, tt.sentinel) {
+				t.Errorf("error = %v, want %v", tt.err, tt.sentinel)
+			}
+		})
+	}
+}
+
+func TestValidationErrorDoesNotLeakToken(t *testing.T) {
+	token := "8600069195406311" + strings.Repeat("7", 100)
+
+	err := ValidateCardToken(token)
+	if err == nil {
+		t.Fatal("ValidateCardToken error = nil, want error")
+	}
+	if strings.Contains(err.Error(), "860006919540") {
+		t.Errorf("error = %v, contains the token", err)
+	}
+}
+
+func TestCurrencyAlphaRoundTrip(t *testing.T) {
+	for code, info := range currencies {
+		alpha := CurrencyAlpha(code)
+		if alpha == "" || alpha != info.Alpha {
+			t.Errorf("CurrencyAlpha(%d) = %q, want %q", code, alpha, info.Alpha)
+		}
+
+		got, ok := CurrencyCodeFromAlpha(alpha)
+		if !ok || got != code {
+			t.Errorf("CurrencyCodeFromAlpha(%q) = %d, %t, want %d", alpha, got, ok, code)
+		}
+
+		// Lowercase with spaces is accepted
+		got, ok = CurrencyCodeFromAlpha(" " + strings.ToLower(alpha) + " ")
+		if !ok || got != code {
+			t.Errorf("CurrencyCodeFromAlpha(%q) = %d, %t, want %d", strings.ToLower(alpha), got, ok, code)
+		}
+	}
+
+	if got := CurrencyAlpha(392); got != "" {
+		t.Errorf("CurrencyAlpha(392) = %q, want empty", got)
+	}
+	if got, ok := CurrencyCodeFromAlpha("JPY"); ok || got != 0 {
+		t.Errorf("CurrencyCodeFromAlpha(JPY) = %d, %t, want 0, false", got, ok)
+	}
+}